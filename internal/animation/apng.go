@@ -0,0 +1,164 @@
+package animation
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+)
+
+// APNGWriter streams an animated PNG. Each frame is encoded on its own and
+// its image data moved into the animation, so only one frame is held at a
+// time.
+type APNGWriter struct {
+	w      *bufio.Writer
+	frames int
+	// delay is the frame duration as a fraction, in the units of fcTL
+	delayNum, delayDen uint16
+	written            int
+	sequence           uint32
+	ihdr               []byte
+	buf                bytes.Buffer
+	encoder            png.Encoder
+}
+
+// NewAPNGWriter starts an animation of the given number of frames, shown
+// at fps frames per second and looping forever
+func NewAPNGWriter(w io.Writer, frames, fps int) (*APNGWriter, error) {
+	if frames < 1 || fps < 1 || fps > 1<<16-1 {
+		return nil, fmt.Errorf("invalid animation of %d frames at %d fps", frames, fps)
+	}
+	aw := &APNGWriter{
+		w:        bufio.NewWriter(w),
+		frames:   frames,
+		delayNum: 1,
+		delayDen: uint16(fps),
+		encoder:  png.Encoder{CompressionLevel: png.BestSpeed},
+	}
+	if _, err := aw.w.WriteString("\x89PNG\r\n\x1a\n"); err != nil {
+		return nil, fmt.Errorf("failed to write PNG signature: %w", err)
+	}
+	return aw, nil
+}
+
+func (aw *APNGWriter) WriteFrame(img image.Image) error {
+	if aw.written == aw.frames {
+		return fmt.Errorf("animation already has its %d frames", aw.frames)
+	}
+	aw.buf.Reset()
+	if err := aw.encoder.Encode(&aw.buf, img); err != nil {
+		return fmt.Errorf("failed to encode frame %d: %w", aw.written, err)
+	}
+	chunks, err := readChunks(aw.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to read frame %d: %w", aw.written, err)
+	}
+
+	var data [][]byte
+	for _, c := range chunks {
+		switch c.kind {
+		case "IHDR":
+			if aw.ihdr == nil {
+				if err := aw.start(c.data); err != nil {
+					return err
+				}
+			} else if !bytes.Equal(c.data, aw.ihdr) {
+				return fmt.Errorf("frame %d differs in size or color type from the first", aw.written)
+			}
+		case "IDAT":
+			data = append(data, c.data)
+		}
+	}
+
+	bounds := img.Bounds()
+	fctl := binary.BigEndian.AppendUint32(nil, aw.next())
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(bounds.Dx()))
+	fctl = binary.BigEndian.AppendUint32(fctl, uint32(bounds.Dy()))
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // x offset
+	fctl = binary.BigEndian.AppendUint32(fctl, 0) // y offset
+	fctl = binary.BigEndian.AppendUint16(fctl, aw.delayNum)
+	fctl = binary.BigEndian.AppendUint16(fctl, aw.delayDen)
+	fctl = append(fctl, 0, 0) // no disposal, source blending
+	if err := pngtext.WriteChunk(aw.w, "fcTL", fctl); err != nil {
+		return err
+	}
+	// The first frame doubles as the still image for viewers without APNG
+	for _, d := range data {
+		var err error
+		if aw.written == 0 {
+			err = pngtext.WriteChunk(aw.w, "IDAT", d)
+		} else {
+			err = pngtext.WriteChunk(aw.w, "fdAT", append(binary.BigEndian.AppendUint32(nil, aw.next()), d...))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	aw.written++
+	return nil
+}
+
+func (aw *APNGWriter) Close() error {
+	if aw.written != aw.frames {
+		return fmt.Errorf("animation has %d of %d frames", aw.written, aw.frames)
+	}
+	if err := pngtext.WriteChunk(aw.w, "IEND", nil); err != nil {
+		return err
+	}
+	if err := aw.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush APNG: %w", err)
+	}
+	return nil
+}
+
+// start writes the header taken from the first frame and the animation
+// control chunk, which has to come before any image data
+func (aw *APNGWriter) start(ihdr []byte) error {
+	aw.ihdr = append([]byte(nil), ihdr...)
+	if err := pngtext.WriteChunk(aw.w, "IHDR", aw.ihdr); err != nil {
+		return err
+	}
+	actl := binary.BigEndian.AppendUint32(nil, uint32(aw.frames))
+	actl = binary.BigEndian.AppendUint32(actl, 0) // loop forever
+	return pngtext.WriteChunk(aw.w, "acTL", actl)
+}
+
+// next returns the next sequence number shared by fcTL and fdAT chunks
+func (aw *APNGWriter) next() uint32 {
+	n := aw.sequence
+	aw.sequence++
+	return n
+}
+
+type chunk struct {
+	kind string
+	data []byte
+}
+
+// readChunks splits an encoded PNG into its chunks
+func readChunks(b []byte) ([]chunk, error) {
+	const signature = 8
+	if len(b) < signature {
+		return nil, errors.New("PNG is truncated")
+	}
+	b = b[signature:]
+	var chunks []chunk
+	for len(b) > 0 {
+		if len(b) < 12 {
+			return nil, errors.New("PNG chunk is truncated")
+		}
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < 12+n {
+			return nil, errors.New("PNG chunk is truncated")
+		}
+		chunks = append(chunks, chunk{kind: string(b[4:8]), data: b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks, nil
+}
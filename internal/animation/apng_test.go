@@ -0,0 +1,154 @@
+package animation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+)
+
+func TestNewAPNGWriter(t *testing.T) {
+	for _, tt := range []struct {
+		frames, fps int
+		ok          bool
+	}{
+		{frames: 1, fps: 1, ok: true},
+		{frames: 100, fps: 1<<16 - 1, ok: true},
+		{frames: 0, fps: 25},
+		{frames: 1, fps: 0},
+		{frames: 1, fps: -1},
+		{frames: 1, fps: 1 << 16},
+	} {
+		if _, err := NewAPNGWriter(io.Discard, tt.frames, tt.fps); (err == nil) != tt.ok {
+			t.Errorf("NewAPNGWriter(%d frames, %d fps) returned %v, want ok %t", tt.frames, tt.fps, err, tt.ok)
+		}
+	}
+}
+
+func TestAPNGWriterFrameCount(t *testing.T) {
+	for _, tt := range []struct {
+		frames, written int
+		writeOK         bool
+		closeOK         bool
+	}{
+		{frames: 2, written: 2, writeOK: true, closeOK: true},
+		{frames: 2, written: 1, writeOK: true},
+		{frames: 2, written: 0, writeOK: true},
+		{frames: 1, written: 2, closeOK: true},
+	} {
+		aw, err := NewAPNGWriter(io.Discard, tt.frames, 25)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = nil
+		for range tt.written {
+			if err = aw.WriteFrame(image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+				break
+			}
+		}
+		if (err == nil) != tt.writeOK {
+			t.Errorf("writing %d of %d frames returned %v, want ok %t", tt.written, tt.frames, err, tt.writeOK)
+		}
+		if err := aw.Close(); (err == nil) != tt.closeOK {
+			t.Errorf("closing after %d of %d frames returned %v, want ok %t", tt.written, tt.frames, err, tt.closeOK)
+		}
+	}
+}
+
+func TestAPNGWriter(t *testing.T) {
+	// Random frames are large enough to be encoded in several IDAT chunks
+	rng := rand.New(rand.NewSource(1))
+	frames := make([]*image.RGBA, 3)
+	for i := range frames {
+		frames[i] = image.NewRGBA(image.Rect(0, 0, 200, 150))
+		rng.Read(frames[i].Pix)
+		for j := 3; j < len(frames[i].Pix); j += 4 {
+			frames[i].Pix[j] = 255
+		}
+	}
+	var buf bytes.Buffer
+	aw, err := NewAPNGWriter(&buf, len(frames), 25)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range frames {
+		if err := aw.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := aw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	chunks, err := readChunks(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) < 2 || chunks[0].kind != "IHDR" || chunks[1].kind != "acTL" {
+		t.Fatal("APNG doesn't start with IHDR and acTL")
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data); got != uint32(len(frames)) {
+		t.Errorf("acTL has %d frames, want %d", got, len(frames))
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data[4:]); got != 0 {
+		t.Errorf("acTL plays %d times, want 0 for forever", got)
+	}
+	if last := chunks[len(chunks)-1]; last.kind != "IEND" {
+		t.Errorf("APNG ends with %s, want IEND", last.kind)
+	}
+
+	// Sequence numbers count up from 0 across fcTL and fdAT. A frame's data
+	// under the animation's header is a still PNG of the frame, which for
+	// the first is the APNG itself.
+	stills := make([]bytes.Buffer, len(frames))
+	frame, sequence := -1, uint32(0)
+	for _, c := range chunks {
+		if c.kind == "fcTL" {
+			frame++
+			stills[frame].WriteString("\x89PNG\r\n\x1a\n")
+			pngtext.WriteChunk(&stills[frame], "IHDR", chunks[0].data)
+		}
+		switch c.kind {
+		case "fcTL", "fdAT":
+			if got := binary.BigEndian.Uint32(c.data); got != sequence {
+				t.Fatalf("%s of frame %d has sequence %d, want %d", c.kind, frame, got, sequence)
+			}
+			sequence++
+		}
+		switch {
+		case c.kind == "fcTL":
+			if num, den := binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:]); num != 1 || den != 25 {
+				t.Errorf("frame %d lasts %d/%d s, want 1/25", frame, num, den)
+			}
+		case c.kind == "fdAT" && frame > 0:
+			pngtext.WriteChunk(&stills[frame], "IDAT", c.data[4:])
+		case c.kind == "IDAT" && frame == 0:
+			pngtext.WriteChunk(&stills[frame], "IDAT", c.data)
+		case c.kind == "fdAT", c.kind == "IDAT":
+			t.Fatalf("%s in frame %d", c.kind, frame)
+		}
+	}
+	if frame != len(frames)-1 {
+		t.Fatalf("APNG has %d frames, want %d", frame+1, len(frames))
+	}
+	for i := range frames {
+		pngtext.WriteChunk(&stills[i], "IEND", nil)
+		img, err := png.Decode(&stills[i])
+		if err != nil {
+			t.Fatalf("failed to decode frame %d: %v", i, err)
+		}
+		if rgba, ok := img.(*image.RGBA); !ok || !bytes.Equal(rgba.Pix, frames[i].Pix) {
+			t.Errorf("frame %d differs from the one written", i)
+		}
+	}
+	if still, err := png.Decode(&buf); err != nil {
+		t.Errorf("viewers without APNG can't decode it: %v", err)
+	} else if rgba, ok := still.(*image.RGBA); !ok || !bytes.Equal(rgba.Pix, frames[0].Pix) {
+		t.Error("viewers without APNG don't see the first frame")
+	}
+}
@@ -0,0 +1,50 @@
+package animation
+
+import "fmt"
+
+// Easing shapes how a value moves from one keyframe to the next
+type Easing string
+
+const (
+	EasingLinear    Easing = "linear"
+	EasingIn        Easing = "ease-in"
+	EasingOut       Easing = "ease-out"
+	EasingInOut     Easing = "ease-in-out"
+	EasingStep      Easing = "step"
+	easingUnchanged Easing = ""
+)
+
+// EasingNames lists the easings in the order help text shows them
+func EasingNames() []string {
+	return []string{string(EasingLinear), string(EasingIn), string(EasingOut), string(EasingInOut), string(EasingStep)}
+}
+
+func (e Easing) Validate() error {
+	switch e {
+	case easingUnchanged, EasingLinear, EasingIn, EasingOut, EasingInOut, EasingStep:
+		return nil
+	default:
+		return fmt.Errorf("unknown easing %q", e)
+	}
+}
+
+// Apply maps the time between two keyframes, from 0 to 1, to how far the
+// value has moved. Unset easings are linear.
+func (e Easing) Apply(t float64) float64 {
+	switch e {
+	case EasingIn:
+		return t * t * t
+	case EasingOut:
+		u := 1 - t
+		return 1 - u*u*u
+	case EasingInOut:
+		return t * t * (3 - 2*t)
+	case EasingStep:
+		if t < 1 {
+			return 0
+		}
+		return 1
+	default:
+		return t
+	}
+}
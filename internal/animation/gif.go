@@ -0,0 +1,40 @@
+package animation
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// GIF gathers frames for an animated GIF. The encoder needs every frame
+// before it writes anything, so frames are kept as paletted images of one
+// byte per pixel.
+type GIF struct {
+	anim  gif.GIF
+	delay int
+}
+
+// NewGIF starts an animation shown at fps frames per second and looping
+// forever. GIF delays are in hundredths of a second.
+func NewGIF(fps int) *GIF {
+	return &GIF{delay: max(2, (100+fps/2)/fps)}
+}
+
+// AddFrame reduces a frame to a fixed palette, the same for every frame so
+// colors don't flicker between them
+func (g *GIF) AddFrame(img image.Image) {
+	paletted := image.NewPaletted(img.Bounds(), palette.Plan9)
+	draw.Draw(paletted, paletted.Rect, img, img.Bounds().Min, draw.Src)
+	g.anim.Image = append(g.anim.Image, paletted)
+	g.anim.Delay = append(g.anim.Delay, g.delay)
+}
+
+func (g *GIF) Encode(w io.Writer) error {
+	if err := gif.EncodeAll(w, &g.anim); err != nil {
+		return fmt.Errorf("failed to encode GIF: %w", err)
+	}
+	return nil
+}
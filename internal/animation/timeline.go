@@ -0,0 +1,171 @@
+package animation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFPS is the frame rate of timelines that don't set one
+const DefaultFPS = 30
+
+// Timeline animates render settings between keyframes. Each setting moves
+// between the keyframes that set it and holds its value before the first
+// and after the last, so keyframes only need to list what changes.
+type Timeline struct {
+	FPS       int        `yaml:"fps,omitempty"`
+	Keyframes []Keyframe `yaml:"keyframes"`
+}
+
+// Keyframe pins settings at a time in seconds. Easing shapes the way from
+// this keyframe to the next one of each setting.
+type Keyframe struct {
+	Time          float64         `yaml:"time"`
+	Easing        Easing          `yaml:"easing,omitempty"`
+	Center        *config.Complex `yaml:"center,omitempty"`
+	Scale         *float64        `yaml:"scale,omitempty"`
+	Iterations    *uint64         `yaml:"iterations,omitempty"`
+	Exponent      *config.Complex `yaml:"exponent,omitempty"`
+	StartingZ     *config.Complex `yaml:"starting-z,omitempty"`
+	StartingC     *config.Complex `yaml:"starting-c,omitempty"`
+	Rotation      *float64        `yaml:"rotation,omitempty"`
+	PaletteOffset *float64        `yaml:"palette-offset,omitempty"`
+}
+
+// LoadTimeline reads and validates a timeline file
+func LoadTimeline(path string) (*Timeline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timeline: %w", err)
+	}
+	var tl Timeline
+	if err := yaml.Unmarshal(data, &tl); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal timeline: %w", err)
+	}
+	if tl.FPS == 0 {
+		tl.FPS = DefaultFPS
+	}
+	if err := tl.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid timeline: %w", err)
+	}
+	return &tl, nil
+}
+
+func (tl *Timeline) Validate() error {
+	if tl.FPS <= 0 {
+		return fmt.Errorf("invalid fps %d", tl.FPS)
+	}
+	if len(tl.Keyframes) == 0 {
+		return errors.New("no keyframes")
+	}
+	for i, k := range tl.Keyframes {
+		if i > 0 && k.Time <= tl.Keyframes[i-1].Time {
+			return fmt.Errorf("keyframe %d at %gs doesn't come after the one before", i, k.Time)
+		}
+		if err := k.Easing.Validate(); err != nil {
+			return fmt.Errorf("keyframe %d: %w", i, err)
+		}
+		if k.Scale != nil && *k.Scale <= 0 {
+			return fmt.Errorf("keyframe %d: invalid scale %g", i, *k.Scale)
+		}
+		if k.Iterations != nil && *k.Iterations == 0 {
+			return fmt.Errorf("keyframe %d: invalid iterations", i)
+		}
+	}
+	return nil
+}
+
+// Duration is the time of the last keyframe
+func (tl *Timeline) Duration() float64 {
+	return tl.Keyframes[len(tl.Keyframes)-1].Time
+}
+
+// Frames is the number of frames, including both ends
+func (tl *Timeline) Frames() int {
+	return int(math.Round(tl.Duration()*float64(tl.FPS))) + 1
+}
+
+// Frame returns the settings of a frame, starting from base for whatever
+// no keyframe sets
+func (tl *Timeline) Frame(base mandelbrot.RenderParams, i int) mandelbrot.RenderParams {
+	return tl.At(base, float64(i)/float64(tl.FPS))
+}
+
+// At returns the settings at a time in seconds
+func (tl *Timeline) At(base mandelbrot.RenderParams, t float64) mandelbrot.RenderParams {
+	p := base
+
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.Scale != nil }); ok {
+		p.View.Scale = *from.Scale * math.Pow(*to.Scale / *from.Scale, u)
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.Center != nil }); ok {
+		if from.Scale != nil && to.Scale != nil {
+			// Zooming while moving keeps the target still on screen
+			p.View.Center = mandelbrot.InterpolateView(
+				mandelbrot.View{Center: complex128(*from.Center), Scale: *from.Scale},
+				mandelbrot.View{Center: complex128(*to.Center), Scale: *to.Scale},
+				u,
+			).Center
+		} else {
+			p.View.Center = lerpComplex(*from.Center, *to.Center, u)
+		}
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.Iterations != nil }); ok {
+		p.MaxIterations = uint64(math.Round(lerp(float64(*from.Iterations), float64(*to.Iterations), u)))
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.Exponent != nil }); ok {
+		p.Params.Exponent = lerpComplex(*from.Exponent, *to.Exponent, u)
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.StartingZ != nil }); ok {
+		p.StartingZ = lerpComplex(*from.StartingZ, *to.StartingZ, u)
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.StartingC != nil }); ok {
+		p.StartingC = lerpComplex(*from.StartingC, *to.StartingC, u)
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.Rotation != nil }); ok {
+		p.Rotation = lerp(*from.Rotation, *to.Rotation, u)
+	}
+	if from, to, u, ok := tl.span(t, func(k *Keyframe) bool { return k.PaletteOffset != nil }); ok {
+		p.Palette = p.Palette.WithOffset(lerp(*from.PaletteOffset, *to.PaletteOffset, u))
+	}
+	return p
+}
+
+// span finds the keyframes setting something on either side of time t,
+// and how far between them t is once eased. Outside the keyframes both are
+// the nearest one. ok is false when no keyframe sets it.
+func (tl *Timeline) span(t float64, sets func(*Keyframe) bool) (from, to *Keyframe, u float64, ok bool) {
+	for i := range tl.Keyframes {
+		k := &tl.Keyframes[i]
+		if !sets(k) {
+			continue
+		}
+		if k.Time <= t || from == nil {
+			from = k
+		}
+		if k.Time >= t {
+			to = k
+			break
+		}
+	}
+	if from == nil {
+		return nil, nil, 0, false
+	}
+	if to == nil || to.Time <= from.Time {
+		return from, from, 0, true
+	}
+	return from, to, from.Easing.Apply((t - from.Time) / (to.Time - from.Time)), true
+}
+
+func lerp(a, b, u float64) float64 {
+	return a + (b-a)*u
+}
+
+func lerpComplex(a, b config.Complex, u float64) complex128 {
+	return complex128(a) + (complex128(b)-complex128(a))*complex(u, 0)
+}
@@ -0,0 +1,53 @@
+package animation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTimelineSpan(t *testing.T) {
+	scale, offset := 4.0, 0.5
+	tl := &Timeline{
+		FPS: DefaultFPS,
+		Keyframes: []Keyframe{
+			{Time: 0, Scale: &scale},
+			{Time: 1, PaletteOffset: &offset},
+			{Time: 2, Scale: &scale, Easing: EasingIn},
+			{Time: 4, Scale: &scale},
+		},
+	}
+	setsScale := func(k *Keyframe) bool { return k.Scale != nil }
+	setsOffset := func(k *Keyframe) bool { return k.PaletteOffset != nil }
+	setsRotation := func(k *Keyframe) bool { return k.Rotation != nil }
+
+	// from and to index the keyframes, -1 when no keyframe sets the value
+	for _, tt := range []struct {
+		name     string
+		sets     func(*Keyframe) bool
+		t        float64
+		from, to int
+		u        float64
+	}{
+		{name: "before the first", sets: setsScale, t: -1, from: 0, to: 0},
+		{name: "on the first", sets: setsScale, t: 0, from: 0, to: 0},
+		{name: "skipping one that doesn't set it", sets: setsScale, t: 1, from: 0, to: 2, u: 0.5},
+		{name: "on one in the middle", sets: setsScale, t: 2, from: 2, to: 2},
+		{name: "eased", sets: setsScale, t: 3, from: 2, to: 3, u: EasingIn.Apply(0.5)},
+		{name: "on the last", sets: setsScale, t: 4, from: 3, to: 3},
+		{name: "after the last", sets: setsScale, t: 5, from: 3, to: 3},
+		{name: "before a single one", sets: setsOffset, t: 0, from: 1, to: 1},
+		{name: "on a single one", sets: setsOffset, t: 1, from: 1, to: 1},
+		{name: "after a single one", sets: setsOffset, t: 5, from: 1, to: 1},
+		{name: "unset", sets: setsRotation, t: 1, from: -1, to: -1},
+	} {
+		from, to, u, ok := tl.span(tt.t, tt.sets)
+		if ok != (tt.from >= 0) {
+			t.Errorf("%s: span(%g) ok is %t", tt.name, tt.t, ok)
+			continue
+		}
+		if ok && (from != &tl.Keyframes[tt.from] || to != &tl.Keyframes[tt.to] || math.Abs(u-tt.u) > 1e-12) {
+			t.Errorf("%s: span(%g) = %gs, %gs, %g, want %gs, %gs, %g", tt.name, tt.t,
+				from.Time, to.Time, u, tl.Keyframes[tt.from].Time, tl.Keyframes[tt.to].Time, tt.u)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package animation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+// DefaultFramesPerDoubling zooms by 2 every second at 30 frames per second
+const DefaultFramesPerDoubling = 30
+
+// Zoom moves from one view to a deeper one at a steady rate, ramping the
+// iteration limit with the depth
+type Zoom struct {
+	From, To mandelbrot.View
+	// FramesPerDoubling is how many frames halving the scale takes
+	FramesPerDoubling float64
+	// FromIterations and ToIterations are the limits at the start and end,
+	// interpolated by the number of doublings between them
+	FromIterations, ToIterations uint64
+}
+
+// Validate checks the zoom can be rendered
+func (z *Zoom) Validate() error {
+	if z.From.Scale <= 0 || z.To.Scale <= 0 {
+		return fmt.Errorf("invalid scales %g and %g", z.From.Scale, z.To.Scale)
+	}
+	if z.FramesPerDoubling <= 0 {
+		return fmt.Errorf("invalid frames per doubling %g", z.FramesPerDoubling)
+	}
+	if z.FromIterations == 0 || z.ToIterations == 0 {
+		return fmt.Errorf("invalid iterations %d and %d", z.FromIterations, z.ToIterations)
+	}
+	return nil
+}
+
+// Frames is the number of frames, including the first and last views
+func (z *Zoom) Frames() int {
+	doublings := math.Abs(math.Log2(z.From.Scale / z.To.Scale))
+	return int(math.Ceil(doublings*z.FramesPerDoubling)) + 1
+}
+
+// Frame returns the view and iteration limit of a frame
+func (z *Zoom) Frame(i int) (mandelbrot.View, uint64) {
+	t := 1.0
+	if frames := z.Frames(); frames > 1 {
+		t = float64(i) / float64(frames-1)
+	}
+	iterations := float64(z.FromIterations) + (float64(z.ToIterations)-float64(z.FromIterations))*t
+	return mandelbrot.InterpolateView(z.From, z.To, t), uint64(math.Round(iterations))
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/animation"
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/spf13/cobra"
+)
+
+func newAnimateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "animate <timeline.yaml>",
+		Short: "Render a keyframe timeline as numbered PNG frames, a GIF or an APNG",
+		Long: "Render a keyframe timeline as numbered PNG frames, a GIF or an APNG.\n" +
+			"Keyframes set the center, scale, iterations, exponent, starting-z, starting-c,\n" +
+			"rotation and palette-offset at a time in seconds, and each eases to the next keyframe\n" +
+			"setting the same thing (" + strings.Join(animation.EasingNames(), ", ") + ").\n" +
+			"Anything no keyframe sets comes from the same config and flags as the explorer.\n" +
+			"The timeline's fps is used unless --fps is given.",
+		Example: "  mandelbrot animate timeline.yaml -o frames/%05d.png --apng timeline.png --width 640 --height 360\n\n" +
+			"  # timeline.yaml\n" +
+			"  fps: 30\n" +
+			"  keyframes:\n" +
+			"    - time: 0\n" +
+			"      easing: ease-in-out\n" +
+			"      exponent: 2\n" +
+			"      palette-offset: 0\n" +
+			"    - time: 4\n" +
+			"      exponent: 3+0.5i\n" +
+			"      palette-offset: 1",
+		Args:          cobra.ExactArgs(1),
+		RunE:          runAnimate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	registerFrameFlags(cmd, "animate/frame-%05d.png")
+	return cmd
+}
+
+func runAnimate(cmd *cobra.Command, args []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+	timeline, err := animation.LoadTimeline(args[0])
+	if err != nil {
+		return err
+	}
+
+	fps := timeline.FPS
+	if cmd.Flags().Changed(framesFPSKey) {
+		if fps, err = cmd.Flags().GetInt(framesFPSKey); err != nil {
+			return fmt.Errorf("failed to get fps: %w", err)
+		}
+		timeline.FPS = fps
+		if err := timeline.Validate(); err != nil {
+			return fmt.Errorf("invalid timeline: %w", err)
+		}
+	}
+
+	frames := timeline.Frames()
+	slog.Info("rendering timeline", "frames", frames, "keyframes", len(timeline.Keyframes), "duration", timeline.Duration(), "width", params.Width, "height", params.Height)
+	return renderFrames(cmd, frames, fps, func(i int) mandelbrot.RenderParams {
+		return timeline.Frame(params, i)
+	})
+}
@@ -5,8 +5,6 @@ import (
 	"log/slog"
 
 	"github.com/USA-RedDragon/mandelbrot/internal/config"
-	"github.com/USA-RedDragon/mandelbrot/internal/game"
-	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/spf13/cobra"
 )
 
@@ -18,20 +16,30 @@ func NewCommand(version, commit string) *cobra.Command {
 			"version": version,
 			"commit":  commit,
 		},
-		RunE:          run,
 		SilenceUsage:  true,
 		SilenceErrors: true,
 	}
-	config.RegisterFlags(cmd)
+	registerExplorer(cmd)
+	cmd.AddCommand(newFractalsCommand())
+	cmd.AddCommand(newExportCommand())
+	cmd.AddCommand(newMandelbulbCommand())
+	cmd.AddCommand(newRenderCommand())
+	cmd.AddCommand(newPosterCommand())
+	cmd.AddCommand(newZoomCommand())
+	cmd.AddCommand(newAnimateCommand())
 	return cmd
 }
 
-func run(cmd *cobra.Command, _ []string) error {
-	slog.Info("mandelbrot", "version", cmd.Annotations["version"], "commit", cmd.Annotations["commit"])
+// software names this program and its version in the images it writes
+func software(cmd *cobra.Command) string {
+	return fmt.Sprintf("mandelbrot %s", cmd.Root().Version)
+}
 
+// loadConfig loads and validates the config, applying its log level
+func loadConfig(cmd *cobra.Command) (*config.Config, error) {
 	cfg, err := config.LoadConfig(cmd)
 	if err != nil {
-		return fmt.Errorf("failed to load config: %w", err)
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	switch cfg.LogLevel {
@@ -47,17 +55,8 @@ func run(cmd *cobra.Command, _ []string) error {
 
 	err = cfg.Validate()
 	if err != nil {
-		return fmt.Errorf("config validation failed: %w", err)
-	}
-
-	game, err := game.NewGame(cfg.Width, cfg.Height)
-	if err != nil {
-		return fmt.Errorf("failed to create game: %w", err)
-	}
-
-	if err := ebiten.RunGame(game); err != nil {
-		return fmt.Errorf("failed to run game: %w", err)
+		return nil, fmt.Errorf("config validation failed: %w", err)
 	}
 
-	return nil
+	return cfg, nil
 }
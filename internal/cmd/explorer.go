@@ -0,0 +1,39 @@
+//go:build !headless
+
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/game"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/spf13/cobra"
+)
+
+// registerExplorer makes the root command open the explorer window
+func registerExplorer(cmd *cobra.Command) {
+	config.RegisterExplorerFlags(cmd)
+	cmd.RunE = run
+}
+
+func run(cmd *cobra.Command, _ []string) error {
+	slog.Info("mandelbrot", "version", cmd.Annotations["version"], "commit", cmd.Annotations["commit"])
+
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	game, err := game.NewGame(cfg, software(cmd))
+	if err != nil {
+		return fmt.Errorf("failed to create game: %w", err)
+	}
+
+	if err := ebiten.RunGame(game); err != nil {
+		return fmt.Errorf("failed to run game: %w", err)
+	}
+
+	return nil
+}
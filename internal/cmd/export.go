@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/export"
+	"github.com/spf13/cobra"
+)
+
+const (
+	exportOutputKey      = "output"
+	exportFieldKey       = "field"
+	exportHeightScaleKey = "height-scale"
+	exportBaseKey        = "base"
+	exportDecimateKey    = "decimate"
+)
+
+func newExportCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a view as a 16 bit heightmap PNG, an STL/OBJ mesh or raw per-pixel fields",
+		Long: "Export a view as a 16 bit heightmap PNG, an STL/OBJ mesh or raw per-pixel fields.\n" +
+			"The format follows the extension of the output file. The view is read from the same\n" +
+			"config and flags as the explorer, with width and height giving the size in pixels.\n" +
+			"NPY and TIFF files hold the raw fields of every pixel (" + strings.Join(export.RawFieldNames(), ", ") + "),\n" +
+			"as a float64 array shaped (height, width, fields) or a TIFF of 32 bit float samples.",
+		Args:          cobra.NoArgs,
+		RunE:          runExport,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	cmd.Flags().StringP(exportOutputKey, "o", "heightmap.png", "Output file, ending in .png, .stl, .obj, .npy, .tif or .tiff")
+	cmd.Flags().String(exportFieldKey, string(export.FieldIterations), "Field used for heights (iterations, distance)")
+	cmd.Flags().Float64(exportHeightScaleKey, 50, "Height of the relief above the base, in pixels")
+	cmd.Flags().Float64(exportBaseKey, 5, "Thickness of the base under the relief, in pixels")
+	cmd.Flags().Int(exportDecimateKey, 1, "Keep every nth sample in each direction of the mesh")
+	return cmd
+}
+
+func runExport(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Flags().GetString(exportOutputKey)
+	if err != nil {
+		return fmt.Errorf("failed to get output: %w", err)
+	}
+	field, err := cmd.Flags().GetString(exportFieldKey)
+	if err != nil {
+		return fmt.Errorf("failed to get field: %w", err)
+	}
+	heightScale, err := cmd.Flags().GetFloat64(exportHeightScaleKey)
+	if err != nil {
+		return fmt.Errorf("failed to get height scale: %w", err)
+	}
+	base, err := cmd.Flags().GetFloat64(exportBaseKey)
+	if err != nil {
+		return fmt.Errorf("failed to get base: %w", err)
+	}
+	decimate, err := cmd.Flags().GetInt(exportDecimateKey)
+	if err != nil {
+		return fmt.Errorf("failed to get decimate: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(output))
+	switch ext {
+	case ".png", ".stl", ".obj", ".npy", ".tif", ".tiff":
+	default:
+		return fmt.Errorf("unsupported output format %q", ext)
+	}
+
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+
+	var (
+		hf  *export.Heightfield
+		raw *export.Raw
+	)
+	switch ext {
+	case ".npy", ".tif", ".tiff":
+		slog.Info("rendering raw fields", "width", params.Width, "height", params.Height)
+		raw = export.NewRaw(params)
+	default:
+		slog.Info("rendering heightfield", "width", params.Width, "height", params.Height, "field", field)
+		hf, err = export.NewHeightfield(params, export.Field(field))
+		if err != nil {
+			return fmt.Errorf("failed to create heightfield: %w", err)
+		}
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	defer f.Close()
+
+	switch ext {
+	case ".npy":
+		err = raw.WriteNPY(f)
+	case ".tif", ".tiff":
+		err = raw.WriteTIFF(f)
+	case ".png":
+		err = hf.WritePNG(f)
+	case ".stl", ".obj":
+		var mesh *export.Mesh
+		mesh, err = export.NewMesh(hf, export.MeshOptions{
+			HeightScale: heightScale,
+			Base:        base,
+			Decimate:    decimate,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create mesh: %w", err)
+		}
+		slog.Info("writing mesh", "vertices", len(mesh.Vertices), "triangles", len(mesh.Triangles))
+		if ext == ".stl" {
+			err = mesh.WriteSTL(f)
+		} else {
+			err = mesh.WriteOBJ(f)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	slog.Info("exported", "output", output)
+	return nil
+}
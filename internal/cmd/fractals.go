@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/spf13/cobra"
+)
+
+func newFractalsCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:           "fractals",
+		Short:         "List the available fractals and their parameters",
+		Args:          cobra.NoArgs,
+		RunE:          runFractals,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+}
+
+func runFractals(cmd *cobra.Command, _ []string) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 4, 2, ' ', 0)
+	for _, fractal := range mandelbrot.Fractals() {
+		fmt.Fprintln(w, fractal.Name())
+		for _, p := range fractal.Parameters() {
+			fmt.Fprintf(w, "  %s\t%s\t(default %g)\n", p.Name, p.Description, p.Default)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write fractals: %w", err)
+	}
+	return nil
+}
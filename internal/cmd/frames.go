@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/animation"
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+	"github.com/spf13/cobra"
+)
+
+const (
+	framesOutputKey = "output"
+	framesFPSKey    = "fps"
+	framesGIFKey    = "gif"
+	framesAPNGKey   = "apng"
+)
+
+// registerFrameFlags adds the flags choosing where animation frames go
+func registerFrameFlags(cmd *cobra.Command, defaultOutput string) {
+	cmd.Flags().StringP(framesOutputKey, "o", defaultOutput, "Pattern of the numbered PNG frames, empty to skip them")
+	cmd.Flags().Int(framesFPSKey, animation.DefaultFPS, "Frames per second of the GIF and APNG")
+	cmd.Flags().String(framesGIFKey, "", "Also write an animated GIF to this file")
+	cmd.Flags().String(framesAPNGKey, "", "Also write an animated PNG to this file")
+}
+
+// renderFrames renders each frame's settings and writes the frames to the
+// outputs chosen by the frame flags. Interrupting stops after the frame
+// being rendered and removes the unfinished APNG.
+func renderFrames(cmd *cobra.Command, frames, fps int, frame func(i int) mandelbrot.RenderParams) error {
+	output, err := cmd.Flags().GetString(framesOutputKey)
+	if err != nil {
+		return fmt.Errorf("failed to get output: %w", err)
+	}
+	if output != "" {
+		if err := checkFramePattern(output); err != nil {
+			return err
+		}
+	}
+	if fps < 1 || fps > 1<<16-1 {
+		return fmt.Errorf("invalid fps %d", fps)
+	}
+	gifPath, err := cmd.Flags().GetString(framesGIFKey)
+	if err != nil {
+		return fmt.Errorf("failed to get gif: %w", err)
+	}
+	apngPath, err := cmd.Flags().GetString(framesAPNGKey)
+	if err != nil {
+		return fmt.Errorf("failed to get apng: %w", err)
+	}
+	if output == "" && gifPath == "" && apngPath == "" {
+		return fmt.Errorf("nothing to write, give frames, --gif or --apng")
+	}
+
+	var (
+		anim         *animation.GIF
+		apng         *animation.APNGWriter
+		apngFile     *os.File
+		apngFinished bool
+	)
+	if gifPath != "" {
+		anim = animation.NewGIF(fps)
+	}
+	if apngPath != "" {
+		if apngFile, err = os.Create(apngPath); err != nil {
+			return fmt.Errorf("failed to create apng: %w", err)
+		}
+		defer apngFile.Close()
+		defer func() {
+			// Unfinished animations don't play
+			if !apngFinished {
+				apngFile.Close()
+				os.Remove(apngPath)
+			}
+		}()
+		if apng, err = animation.NewAPNGWriter(apngFile, frames, fps); err != nil {
+			return fmt.Errorf("failed to start apng: %w", err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	for i := range frames {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d of %d frames: %w", i, frames, ctx.Err())
+		}
+		params := frame(i)
+		img := mandelbrot.Render(params)
+
+		if output != "" {
+			text, err := config.ImageText(params, software(cmd))
+			if err != nil {
+				return err
+			}
+			if err := writePNG(fmt.Sprintf(output, i), img, text); err != nil {
+				return err
+			}
+		}
+		if anim != nil {
+			anim.AddFrame(img)
+		}
+		if apng != nil {
+			if err := apng.WriteFrame(img); err != nil {
+				return fmt.Errorf("failed to write apng: %w", err)
+			}
+		}
+		slog.Info("rendered frame", "frame", i+1, "of", frames, "scale", params.View.Scale, "iterations", params.MaxIterations, "elapsed", time.Since(start).Round(time.Second))
+	}
+
+	if apng != nil {
+		if err := apng.Close(); err != nil {
+			return fmt.Errorf("failed to finish apng: %w", err)
+		}
+		if err := apngFile.Close(); err != nil {
+			return fmt.Errorf("failed to write apng: %w", err)
+		}
+		apngFinished = true
+		slog.Info("exported", "output", apngPath)
+	}
+	if anim != nil {
+		f, err := os.Create(gifPath)
+		if err != nil {
+			return fmt.Errorf("failed to create gif: %w", err)
+		}
+		defer f.Close()
+		if err := anim.Encode(f); err != nil {
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write gif: %w", err)
+		}
+		slog.Info("exported", "output", gifPath)
+	}
+	slog.Info("done", "frames", frames, "duration", time.Since(start))
+	return nil
+}
+
+// checkFramePattern makes sure a frame pattern numbers its files with
+// exactly one integer verb, %d or %0Nd, and no other verbs
+func checkFramePattern(pattern string) error {
+	verbs := 0
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(pattern) && pattern[i] == '%' {
+			continue
+		}
+		width := i
+		if i < len(pattern) && pattern[i] == '0' {
+			i++
+			for i < len(pattern) && pattern[i] >= '0' && pattern[i] <= '9' {
+				i++
+			}
+			if i == width+1 {
+				return fmt.Errorf("output %q has a frame number with no width after %%0", pattern)
+			}
+		}
+		if i >= len(pattern) || pattern[i] != 'd' {
+			return fmt.Errorf("output %q can only hold a frame number like %%d or %%05d", pattern)
+		}
+		verbs++
+	}
+	if verbs != 1 {
+		return fmt.Errorf("output %q needs exactly one frame number like %%05d", pattern)
+	}
+	return nil
+}
+
+// writePNG writes one frame with its text, creating its directory
+func writePNG(path string, img *image.RGBA, text []pngtext.Text) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create frame directory: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create frame: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(pngtext.NewWriter(f, text...), img); err != nil {
+		return fmt.Errorf("failed to encode frame: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write frame: %w", err)
+	}
+	return nil
+}
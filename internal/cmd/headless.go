@@ -0,0 +1,11 @@
+//go:build headless
+
+package cmd
+
+import "github.com/spf13/cobra"
+
+// registerExplorer leaves the explorer out of headless builds, which don't
+// link the windowing libraries. The root command only lists the commands.
+func registerExplorer(cmd *cobra.Command) {
+	cmd.Long = "Built without the explorer window, only the headless commands are available."
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"image/png"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/raymarch"
+	"github.com/spf13/cobra"
+)
+
+const (
+	mandelbulbOutputKey     = "output"
+	mandelbulbPowerKey      = "power"
+	mandelbulbIterationsKey = "bulb-iterations"
+	mandelbulbYawKey        = "yaw"
+	mandelbulbPitchKey      = "pitch"
+	mandelbulbDistanceKey   = "distance"
+	mandelbulbFOVKey        = "fov"
+	mandelbulbShadowsKey    = "shadows"
+	mandelbulbOcclusionKey  = "occlusion"
+)
+
+func newMandelbulbCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mandelbulb",
+		Short: "Ray march a Mandelbulb to a PNG",
+		Long: "Ray march a Mandelbulb to a PNG.\n" +
+			"The camera orbits the bulb's center, width and height give the size in pixels.",
+		Args:          cobra.NoArgs,
+		RunE:          runMandelbulb,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	defaults := raymarch.DefaultParams(0, 0)
+	bulb := raymarch.NewMandelbulb(8)
+	cmd.Flags().StringP(mandelbulbOutputKey, "o", "mandelbulb.png", "Output PNG file")
+	cmd.Flags().Float64(mandelbulbPowerKey, bulb.Power, "Power of the bulb formula")
+	cmd.Flags().Int(mandelbulbIterationsKey, bulb.Iterations, "Iterations of the distance estimate")
+	cmd.Flags().Float64(mandelbulbYawKey, defaults.Camera.Yaw, "Camera angle around the vertical axis in degrees")
+	cmd.Flags().Float64(mandelbulbPitchKey, defaults.Camera.Pitch, "Camera angle above the horizontal plane in degrees")
+	cmd.Flags().Float64(mandelbulbDistanceKey, defaults.Camera.Distance, "Camera distance from the center")
+	cmd.Flags().Float64(mandelbulbFOVKey, defaults.Camera.FOV, "Vertical field of view in degrees")
+	cmd.Flags().Bool(mandelbulbShadowsKey, defaults.Shadows, "Cast soft shadows")
+	cmd.Flags().Bool(mandelbulbOcclusionKey, defaults.AmbientOcclusion, "Darken creases with ambient occlusion")
+	config.RegisterBaseFlags(cmd)
+	return cmd
+}
+
+func runMandelbulb(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Flags().GetString(mandelbulbOutputKey)
+	if err != nil {
+		return fmt.Errorf("failed to get output: %w", err)
+	}
+
+	params := raymarch.DefaultParams(int(cfg.Width), int(cfg.Height))
+	bulb := raymarch.NewMandelbulb(8)
+	floats := []struct {
+		key   string
+		value *float64
+	}{
+		{mandelbulbPowerKey, &bulb.Power},
+		{mandelbulbYawKey, &params.Camera.Yaw},
+		{mandelbulbPitchKey, &params.Camera.Pitch},
+		{mandelbulbDistanceKey, &params.Camera.Distance},
+		{mandelbulbFOVKey, &params.Camera.FOV},
+	}
+	for _, f := range floats {
+		if *f.value, err = cmd.Flags().GetFloat64(f.key); err != nil {
+			return fmt.Errorf("failed to get %s: %w", f.key, err)
+		}
+	}
+	if bulb.Iterations, err = cmd.Flags().GetInt(mandelbulbIterationsKey); err != nil {
+		return fmt.Errorf("failed to get iterations: %w", err)
+	}
+	if params.Shadows, err = cmd.Flags().GetBool(mandelbulbShadowsKey); err != nil {
+		return fmt.Errorf("failed to get shadows: %w", err)
+	}
+	if params.AmbientOcclusion, err = cmd.Flags().GetBool(mandelbulbOcclusionKey); err != nil {
+		return fmt.Errorf("failed to get occlusion: %w", err)
+	}
+	params.Estimator = bulb
+
+	slog.Info("ray marching mandelbulb", "width", params.Width, "height", params.Height, "power", bulb.Power)
+	start := time.Now()
+	img := raymarch.Render(params)
+	slog.Info("rendered", "duration", time.Since(start))
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(f, img); err != nil {
+		return fmt.Errorf("failed to encode png: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	slog.Info("exported", "output", output)
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+	"github.com/USA-RedDragon/mandelbrot/internal/poster"
+	"github.com/spf13/cobra"
+)
+
+const (
+	posterOutputKey      = "output"
+	posterStripHeightKey = "strip-height"
+)
+
+func newPosterCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "poster",
+		Short: "Render a view of any size to a PNG or TIFF in strips",
+		Long: "Render a view of any size to a PNG or TIFF in strips.\n" +
+			"Only one strip is held in memory at a time, and rows are encoded to disk as they are\n" +
+			"rendered. The format follows the extension of the output file. The view is read from the\n" +
+			"same config and flags as the explorer, with width and height giving the size in pixels.\n" +
+			"Interrupting removes the unfinished file.",
+		Example:       "  mandelbrot poster -o poster.png --width 30000 --height 20000 --view.iterations 5000",
+		Args:          cobra.NoArgs,
+		RunE:          runPoster,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	cmd.Flags().StringP(posterOutputKey, "o", "poster.png", "Output file, ending in .png, .tif or .tiff")
+	cmd.Flags().Int(posterStripHeightKey, poster.DefaultStripHeight, "Rows rendered and held in memory at a time")
+	return cmd
+}
+
+func runPoster(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Flags().GetString(posterOutputKey)
+	if err != nil {
+		return fmt.Errorf("failed to get output: %w", err)
+	}
+	stripHeight, err := cmd.Flags().GetInt(posterStripHeightKey)
+	if err != nil {
+		return fmt.Errorf("failed to get strip height: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(output))
+	switch ext {
+	case ".png", ".tif", ".tiff":
+	default:
+		return fmt.Errorf("unsupported output format %q", ext)
+	}
+
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+
+	text, err := config.ImageText(params, software(cmd))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	defer f.Close()
+
+	var w poster.StripWriter
+	if ext == ".png" {
+		w, err = poster.NewPNGWriter(pngtext.NewWriter(f, text...), params.Width, params.Height)
+	} else {
+		w, err = poster.NewTIFFWriter(f, params.Width, params.Height)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(output)
+		return fmt.Errorf("failed to start output: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	slog.Info("rendering poster", "width", params.Width, "height", params.Height, "strip-height", stripHeight)
+	start := time.Now()
+	progress := newPosterProgress(cmd, start)
+	err = poster.Render(ctx, params, w, poster.Options{
+		StripHeight: stripHeight,
+		Progress:    progress,
+	})
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err == nil {
+		err = w.Close()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(output)
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted, removed %s: %w", output, err)
+		}
+		return fmt.Errorf("failed to render poster: %w", err)
+	}
+	slog.Info("exported", "output", output, "duration", time.Since(start))
+	return nil
+}
+
+// newPosterProgress returns a progress callback that keeps a line on stderr
+// up to date with the rows done and the time left
+func newPosterProgress(cmd *cobra.Command, start time.Time) func(rows, total int) {
+	return func(rows, total int) {
+		elapsed := time.Since(start)
+		remaining := time.Duration(float64(elapsed) * float64(total-rows) / float64(rows))
+		fmt.Fprintf(cmd.ErrOrStderr(), "\r%5.1f%% %d/%d rows, %s left   ",
+			100*float64(rows)/float64(total), rows, total, remaining.Round(time.Second))
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+	"github.com/spf13/cobra"
+	"golang.org/x/image/tiff"
+)
+
+const (
+	renderOutputKey  = "output"
+	renderQualityKey = "quality"
+	renderDepthKey   = "depth"
+)
+
+func newRenderCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "render",
+		Short: "Render a view to a PNG, JPEG or TIFF without opening a window",
+		Long: "Render a view to a PNG, JPEG or TIFF without opening a window.\n" +
+			"The format follows the extension of the output file. The view is read from the same\n" +
+			"config and flags as the explorer, with width and height giving the size in pixels.\n" +
+			"PNG and TIFF can hold 16 bits per channel with --depth 16. PNGs carry the view in\n" +
+			"their text, which --config restores when given the image.",
+		Example: "  mandelbrot render -o seahorse.png --width 1920 --height 1080 \\\n" +
+			"    --view.center=-0.745+0.105i --view.scale 0.01 --view.iterations 2000 --view.palette grayscale",
+		Args:          cobra.NoArgs,
+		RunE:          runRender,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	cmd.Flags().StringP(renderOutputKey, "o", "mandelbrot.png", "Output file, ending in .png, .jpg, .jpeg, .tif or .tiff")
+	cmd.Flags().Int(renderQualityKey, jpeg.DefaultQuality, "JPEG quality from 1 to 100")
+	cmd.Flags().Int(renderDepthKey, 8, "Bits per channel, 8 or 16 for PNG and TIFF")
+	return cmd
+}
+
+func runRender(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	output, err := cmd.Flags().GetString(renderOutputKey)
+	if err != nil {
+		return fmt.Errorf("failed to get output: %w", err)
+	}
+	quality, err := cmd.Flags().GetInt(renderQualityKey)
+	if err != nil {
+		return fmt.Errorf("failed to get quality: %w", err)
+	}
+	depth, err := cmd.Flags().GetInt(renderDepthKey)
+	if err != nil {
+		return fmt.Errorf("failed to get depth: %w", err)
+	}
+
+	ext := strings.ToLower(filepath.Ext(output))
+	switch ext {
+	case ".png", ".jpg", ".jpeg", ".tif", ".tiff":
+	default:
+		return fmt.Errorf("unsupported output format %q", ext)
+	}
+	switch {
+	case depth != 8 && depth != 16:
+		return fmt.Errorf("invalid depth %d", depth)
+	case depth == 16 && (ext == ".jpg" || ext == ".jpeg"):
+		return fmt.Errorf("JPEG has no 16 bit depth, use PNG or TIFF")
+	}
+	if quality < 1 || quality > 100 {
+		return fmt.Errorf("invalid quality %d", quality)
+	}
+
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+
+	slog.Info("rendering", "fractal", params.Fractal.Name(), "width", params.Width, "height", params.Height, "depth", depth, "center", params.View.Center, "scale", params.View.Scale)
+	start := time.Now()
+	var img image.Image
+	if depth == 16 {
+		img = mandelbrot.Render64(params)
+	} else {
+		img = mandelbrot.Render(params)
+	}
+	slog.Info("rendered", "duration", time.Since(start))
+
+	text, err := config.ImageText(params, software(cmd))
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return fmt.Errorf("failed to create output: %w", err)
+	}
+	defer f.Close()
+
+	switch ext {
+	case ".png":
+		err = png.Encode(pngtext.NewWriter(f, text...), img)
+	case ".tif", ".tiff":
+		err = tiff.Encode(f, img, &tiff.Options{Compression: tiff.Deflate})
+	default:
+		err = jpeg.Encode(f, img, &jpeg.Options{Quality: quality})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	slog.Info("exported", "output", output)
+	return nil
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/animation"
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/spf13/cobra"
+)
+
+const (
+	zoomFromCenterKey        = "from-center"
+	zoomFromScaleKey         = "from-scale"
+	zoomFromIterationsKey    = "from-iterations"
+	zoomFramesPerDoublingKey = "frames-per-doubling"
+)
+
+func newZoomCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "zoom",
+		Short: "Render a zoom into a view as numbered PNG frames, a GIF or an APNG",
+		Long: "Render a zoom into a view as numbered PNG frames, a GIF or an APNG.\n" +
+			"The zoom ends at the view read from the same config and flags as the explorer, and\n" +
+			"starts from the fractal's default view unless --from-center and --from-scale say\n" +
+			"otherwise. The scale halves every --frames-per-doubling frames and the iteration limit\n" +
+			"ramps from --from-iterations to view.iterations.",
+		Example: "  mandelbrot zoom -o frames/%05d.png --apng zoom.png --width 640 --height 360 \\\n" +
+			"    --view.center=-0.743643887037151+0.13182590420533i --view.scale 1e-8 --view.iterations 3000",
+		Args:          cobra.NoArgs,
+		RunE:          runZoom,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	registerFrameFlags(cmd, "zoom/frame-%05d.png")
+	cmd.Flags().String(zoomFromCenterKey, "", "Center of the first frame, defaults to the fractal's default view")
+	cmd.Flags().Float64(zoomFromScaleKey, 0, "Scale of the first frame, defaults to the fractal's default view")
+	cmd.Flags().Uint64(zoomFromIterationsKey, 250, "Iteration limit of the first frame")
+	cmd.Flags().Float64(zoomFramesPerDoublingKey, animation.DefaultFramesPerDoubling, "Frames it takes to zoom in by 2")
+	return cmd
+}
+
+func runZoom(cmd *cobra.Command, _ []string) error {
+	cfg, err := loadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read view: %w", err)
+	}
+	zoom, err := zoomFromFlags(cmd, params)
+	if err != nil {
+		return err
+	}
+	if err := zoom.Validate(); err != nil {
+		return fmt.Errorf("invalid zoom: %w", err)
+	}
+
+	fps, err := cmd.Flags().GetInt(framesFPSKey)
+	if err != nil {
+		return fmt.Errorf("failed to get fps: %w", err)
+	}
+
+	frames := zoom.Frames()
+	slog.Info("rendering zoom", "frames", frames, "from", zoom.From.Scale, "to", zoom.To.Scale, "width", params.Width, "height", params.Height)
+	return renderFrames(cmd, frames, fps, func(i int) mandelbrot.RenderParams {
+		params.View, params.MaxIterations = zoom.Frame(i)
+		return params
+	})
+}
+
+// zoomFromFlags builds the zoom from the fractal's default view, or the
+// start given by flags, to the configured view
+func zoomFromFlags(cmd *cobra.Command, params mandelbrot.RenderParams) (*animation.Zoom, error) {
+	zoom := &animation.Zoom{
+		From:         params.Fractal.DefaultView(),
+		To:           params.View,
+		ToIterations: params.MaxIterations,
+	}
+	if cmd.Flags().Changed(zoomFromCenterKey) {
+		s, err := cmd.Flags().GetString(zoomFromCenterKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get from center: %w", err)
+		}
+		var c config.Complex
+		if err := c.UnmarshalText([]byte(s)); err != nil {
+			return nil, fmt.Errorf("failed to parse from center: %w", err)
+		}
+		zoom.From.Center = complex128(c)
+	}
+	if cmd.Flags().Changed(zoomFromScaleKey) {
+		scale, err := cmd.Flags().GetFloat64(zoomFromScaleKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get from scale: %w", err)
+		}
+		zoom.From.Scale = scale
+	}
+	var err error
+	if zoom.FromIterations, err = cmd.Flags().GetUint64(zoomFromIterationsKey); err != nil {
+		return nil, fmt.Errorf("failed to get from iterations: %w", err)
+	}
+	if zoom.FramesPerDoubling, err = cmd.Flags().GetFloat64(zoomFramesPerDoublingKey); err != nil {
+		return nil, fmt.Errorf("failed to get frames per doubling: %w", err)
+	}
+	return zoom, nil
+}
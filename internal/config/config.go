@@ -1,11 +1,15 @@
 package config
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
 	"github.com/go-errors/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -13,9 +17,23 @@ import (
 )
 
 type Config struct {
-	LogLevel LogLevel `json:"log-level" yaml:"log-level"`
-	Width    uint     `json:"width" yaml:"width"`
-	Height   uint     `json:"height" yaml:"height"`
+	LogLevel  LogLevel  `json:"log-level" yaml:"log-level"`
+	Width     uint      `json:"width" yaml:"width"`
+	Height    uint      `json:"height" yaml:"height"`
+	TileCache TileCache `json:"tile-cache" yaml:"tile-cache"`
+	Fractal   string    `json:"fractal" yaml:"fractal"`
+	View      View      `json:"view" yaml:"view"`
+	// Timeline is a keyframe timeline for the explorer to play
+	Timeline string `json:"timeline" yaml:"timeline"`
+}
+
+// TileCache configures the explorer's tile cache. Tiles are resampled onto
+// the screen, which blurs the view a little, so the cache is only used when
+// enabled.
+type TileCache struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Size    uint   `json:"size" yaml:"size"`
+	Dir     string `json:"dir" yaml:"dir"`
 }
 
 type LogLevel string
@@ -29,30 +47,77 @@ const (
 
 //nolint:golint,gochecknoglobals
 var (
-	ConfigFileKey = "config"
-	LogLevelKey   = "log-level"
-	WidthKey      = "width"
-	HeightKey     = "height"
+	ConfigFileKey        = "config"
+	LogLevelKey          = "log-level"
+	WidthKey             = "width"
+	HeightKey            = "height"
+	TileCacheEnabledKey  = "tile-cache.enabled"
+	TileCacheSizeKey     = "tile-cache.size"
+	TileCacheDirKey      = "tile-cache.dir"
+	FractalKey           = "fractal"
+	ViewCenterKey        = "view.center"
+	ViewScaleKey         = "view.scale"
+	ViewIterationsKey    = "view.iterations"
+	ViewExponentKey      = "view.exponent"
+	ViewStartingZKey     = "view.starting-z"
+	ViewStartingCKey     = "view.starting-c"
+	ViewJuliaKey         = "view.julia"
+	ViewJuliaMethodKey   = "view.julia-method"
+	ViewHybridKey        = "view.hybrid"
+	ViewPaletteKey       = "view.palette"
+	ViewPaletteOffsetKey = "view.palette-offset"
+	ViewRotationKey      = "view.rotation"
+	ViewPlaneOriginKey   = "view.plane.origin"
+	ViewPlaneUKey        = "view.plane.u"
+	ViewPlaneVKey        = "view.plane.v"
+	TimelineKey          = "timeline"
 )
 
 const (
-	DefaultConfigPath = "config.yaml"
-	DefaultLogLevel   = LogLevelInfo
-	DefaultWidth      = 720
-	DefaultHeight     = 480
+	DefaultConfigPath    = "config.yaml"
+	DefaultLogLevel      = LogLevelInfo
+	DefaultWidth         = 720
+	DefaultHeight        = 480
+	DefaultTileCacheSize = 2048
+	DefaultFractal       = mandelbrot.FractalMandelbrot
 )
 
-func RegisterFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP(ConfigFileKey, "c", DefaultConfigPath, "Config file path")
+// RegisterBaseFlags registers the flags shared by every command, for
+// commands that don't render a view of the plane
+func RegisterBaseFlags(cmd *cobra.Command) {
+	cmd.Flags().StringP(ConfigFileKey, "c", DefaultConfigPath, "Config file path, or a PNG rendered by this program to restore its view")
 	cmd.Flags().String(LogLevelKey, string(DefaultLogLevel), "Log level")
-	cmd.Flags().Uint(WidthKey, DefaultWidth, "Initial window width")
-	cmd.Flags().Uint(HeightKey, DefaultHeight, "Initial window height")
+	cmd.Flags().Uint(WidthKey, DefaultWidth, "Width of the window or image")
+	cmd.Flags().Uint(HeightKey, DefaultHeight, "Height of the window or image")
+}
+
+func RegisterFlags(cmd *cobra.Command) {
+	RegisterBaseFlags(cmd)
+	cmd.Flags().String(FractalKey, DefaultFractal, fmt.Sprintf("Fractal to explore (%s)", strings.Join(mandelbrot.FractalNames(), ", ")))
+	registerViewFlags(cmd)
+}
+
+// RegisterExplorerFlags registers the flags only the explorer window has
+func RegisterExplorerFlags(cmd *cobra.Command) {
+	RegisterFlags(cmd)
+	cmd.Flags().Bool(TileCacheEnabledKey, false, "Draw the explorer from cached tiles, which reuses work but resamples pixels")
+	cmd.Flags().Uint(TileCacheSizeKey, DefaultTileCacheSize, "Number of tiles to keep in memory")
+	cmd.Flags().String(TileCacheDirKey, "", "Directory to persist tiles in, empty to keep them in memory only")
+	cmd.Flags().String(TimelineKey, "", "Keyframe timeline to play when the window opens, T replays it")
 }
 
 var (
-	ErrInvalidLogLevel = errors.New("Invalid log level")
-	ErrInvalidWidth    = errors.New("Invalid width")
-	ErrInvalidHeight   = errors.New("Invalid height")
+	ErrInvalidLogLevel    = errors.New("Invalid log level")
+	ErrInvalidWidth       = errors.New("Invalid width")
+	ErrInvalidHeight      = errors.New("Invalid height")
+	ErrInvalidFractal     = errors.New("Invalid fractal")
+	ErrInvalidScale       = errors.New("Invalid scale")
+	ErrInvalidJuliaMethod = errors.New("Invalid julia method")
+	ErrInvalidHybrid      = errors.New("Invalid hybrid")
+	ErrInvalidPalette     = errors.New("Invalid palette")
+	ErrInvalidParameter   = errors.New("Invalid parameter")
+	ErrInvalidColoring    = errors.New("Invalid coloring")
+	ErrInvalidPlane       = errors.New("Invalid plane, it needs both u and v")
 )
 
 func (c *Config) Validate() error {
@@ -70,6 +135,46 @@ func (c *Config) Validate() error {
 		return ErrInvalidHeight
 	}
 
+	fractal, ok := mandelbrot.LookupFractal(c.Fractal)
+	if !ok {
+		return ErrInvalidFractal
+	}
+	for name := range c.View.Parameters {
+		if _, err := mandelbrot.ParameterIndex(fractal, name); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidParameter, err)
+		}
+	}
+
+	if c.View.Scale < 0 {
+		return ErrInvalidScale
+	}
+
+	switch mandelbrot.JuliaMethod(c.View.JuliaMethod) {
+	case "", mandelbrot.JuliaMethodEscapeTime, mandelbrot.JuliaMethodInverse:
+	default:
+		return ErrInvalidJuliaMethod
+	}
+
+	if c.View.Palette != "" {
+		if _, ok := mandelbrot.LookupPaletteMode(c.View.Palette); !ok {
+			return ErrInvalidPalette
+		}
+	}
+
+	if c.View.Hybrid != "" {
+		if _, err := mandelbrot.ParseHybrid(c.View.Hybrid); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidHybrid, err)
+		}
+	}
+
+	if c.View.Plane != nil && (c.View.Plane.U == nil || c.View.Plane.V == nil) {
+		return ErrInvalidPlane
+	}
+
+	if c.View.Coloring != nil && !slices.Contains(mandelbrot.ColoringMethods(), mandelbrot.ColoringMethod(c.View.Coloring.Method)) {
+		return ErrInvalidColoring
+	}
+
 	return nil
 }
 
@@ -100,6 +205,10 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 	}
 	if configPath != "" {
 		data, err := os.ReadFile(configPath)
+		if err == nil && strings.EqualFold(filepath.Ext(configPath), ".png") {
+			// Images rendered here carry the config of their view
+			data, err = ReadImageView(bytes.NewReader(data))
+		}
 		if err != nil && !errors.Is(err, os.ErrNotExist) {
 			return &config, fmt.Errorf("failed to read config: %w", err)
 		} else if err == nil {
@@ -114,20 +223,31 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 		return &config, fmt.Errorf("failed to override flags: %w", err)
 	}
 
-	// Defaults
-	if config.LogLevel == "" {
-		config.LogLevel = DefaultLogLevel
+	config.setDefaults()
+
+	return &config, nil
+}
+
+func (c *Config) setDefaults() {
+	if c.LogLevel == "" {
+		c.LogLevel = DefaultLogLevel
+	}
+
+	if c.Width == 0 {
+		c.Width = DefaultWidth
 	}
 
-	if config.Width == 0 {
-		config.Width = DefaultWidth
+	if c.Height == 0 {
+		c.Height = DefaultHeight
 	}
 
-	if config.Height == 0 {
-		config.Height = DefaultHeight
+	if c.TileCache.Size == 0 {
+		c.TileCache.Size = DefaultTileCacheSize
 	}
 
-	return &config, nil
+	if c.Fractal == "" {
+		c.Fractal = DefaultFractal
+	}
 }
 
 func overrideFlags(config *Config, cmd *cobra.Command) error {
@@ -155,5 +275,49 @@ func overrideFlags(config *Config, cmd *cobra.Command) error {
 		config.Height = h
 	}
 
+	if cmd.Flags().Changed(TileCacheEnabledKey) {
+		enabled, err := cmd.Flags().GetBool(TileCacheEnabledKey)
+		if err != nil {
+			return fmt.Errorf("failed to get tile cache enabled: %w", err)
+		}
+		config.TileCache.Enabled = enabled
+	}
+
+	if cmd.Flags().Changed(TileCacheSizeKey) {
+		size, err := cmd.Flags().GetUint(TileCacheSizeKey)
+		if err != nil {
+			return fmt.Errorf("failed to get tile cache size: %w", err)
+		}
+		config.TileCache.Size = size
+	}
+
+	if cmd.Flags().Changed(TileCacheDirKey) {
+		dir, err := cmd.Flags().GetString(TileCacheDirKey)
+		if err != nil {
+			return fmt.Errorf("failed to get tile cache dir: %w", err)
+		}
+		config.TileCache.Dir = dir
+	}
+
+	if cmd.Flags().Changed(FractalKey) {
+		fractal, err := cmd.Flags().GetString(FractalKey)
+		if err != nil {
+			return fmt.Errorf("failed to get fractal: %w", err)
+		}
+		config.Fractal = fractal
+	}
+
+	if cmd.Flags().Changed(TimelineKey) {
+		timeline, err := cmd.Flags().GetString(TimelineKey)
+		if err != nil {
+			return fmt.Errorf("failed to get timeline: %w", err)
+		}
+		config.Timeline = timeline
+	}
+
+	if err := overrideViewFlags(&config.View, cmd); err != nil {
+		return err
+	}
+
 	return nil
 }
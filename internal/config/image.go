@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+	"github.com/go-errors/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// ImageViewKeyword is the PNG text keyword holding the view an image was
+// rendered from, as a config file
+const ImageViewKeyword = "mandelbrot.view"
+
+var ErrNoImageView = errors.New("Image has no saved view")
+
+// ImageText describes a render for the text chunks of a PNG. Image viewers
+// show the fields, and the view is kept whole as a config file for
+// --config and the explorer to restore.
+func ImageText(p mandelbrot.RenderParams, software string) ([]pngtext.Text, error) {
+	view, err := MarshalView(p)
+	if err != nil {
+		return nil, err
+	}
+	format := func(c complex128) string {
+		text, _ := Complex(c).MarshalText()
+		return string(text)
+	}
+	return []pngtext.Text{
+		{Keyword: "Software", Value: software},
+		{Keyword: "Fractal", Value: p.Fractal.Name()},
+		{Keyword: "Center", Value: format(p.View.Center)},
+		{Keyword: "Scale", Value: strconv.FormatFloat(p.View.Scale, 'g', -1, 64)},
+		{Keyword: "Iterations", Value: strconv.FormatUint(p.MaxIterations, 10)},
+		{Keyword: "Exponent", Value: format(p.Params.Exponent)},
+		{Keyword: "Starting Z", Value: format(p.StartingZ)},
+		{Keyword: "Starting C", Value: format(p.StartingC)},
+		{Keyword: "Julia", Value: strconv.FormatBool(p.Julia)},
+		{Keyword: "Palette", Value: p.Palette.Mode().String()},
+		{Keyword: ImageViewKeyword, Value: string(view), UTF8: true},
+	}, nil
+}
+
+// ReadImageView returns the config file saved in a PNG's text
+func ReadImageView(r io.Reader) ([]byte, error) {
+	texts, err := pngtext.Read(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image text: %w", err)
+	}
+	view, ok := pngtext.Lookup(texts, ImageViewKeyword)
+	if !ok {
+		return nil, ErrNoImageView
+	}
+	return []byte(view), nil
+}
+
+// LoadImage loads the config saved in a PNG's text
+func LoadImage(r io.Reader) (*Config, error) {
+	data, err := ReadImageView(r)
+	if err != nil {
+		return nil, err
+	}
+	var config Config
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal image view: %w", err)
+	}
+	config.setDefaults()
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid image view: %w", err)
+	}
+	return &config, nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+)
+
+func TestImageView(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		set  func(p *mandelbrot.RenderParams)
+	}{
+		{name: "default", set: func(*mandelbrot.RenderParams) {}},
+		{name: "zoomed", set: func(p *mandelbrot.RenderParams) {
+			p.View = mandelbrot.View{Center: complex(-0.743643887037151, 0.13182590420533), Scale: 1e-4}
+			p.MaxIterations = 3000
+		}},
+		{name: "rotated", set: func(p *mandelbrot.RenderParams) {
+			p.Rotation = 30
+		}},
+		{name: "palette", set: func(p *mandelbrot.RenderParams) {
+			p.Palette = mandelbrot.NewPalette(mandelbrot.PaletteModeSimpleGrayscale).WithOffset(0.25)
+		}},
+		{name: "julia", set: func(p *mandelbrot.RenderParams) {
+			p.Julia = true
+			p.StartingC = complex(0.285, 0.01)
+			p.Params.Exponent = 3
+		}},
+		{name: "hybrid", set: func(p *mandelbrot.RenderParams) {
+			p.Fractal, _ = mandelbrot.LookupFractal(mandelbrot.FractalHybrid)
+			p.Params.Hybrid, _ = mandelbrot.ParseHybrid("mandelbrot,burning-ship^3")
+		}},
+		{name: "parameters", set: func(p *mandelbrot.RenderParams) {
+			p.Params.Values[0] = 1000
+		}},
+		{name: "lighting", set: func(p *mandelbrot.RenderParams) {
+			p.Lighting.Enabled = true
+			p.Lighting.Azimuth = 120
+		}},
+		{name: "coloring", set: func(p *mandelbrot.RenderParams) {
+			p.Coloring.Method = mandelbrot.ColoringStripe
+			p.Coloring.StripeDensity = 3
+		}},
+		{name: "contours", set: func(p *mandelbrot.RenderParams) {
+			p.Contours.FieldLines = true
+			p.Contours.Color = [4]byte{10, 20, 30, 40}
+		}},
+		{name: "rotated plane", set: func(p *mandelbrot.RenderParams) {
+			plane := mandelbrot.RotatedPlane(complex(0.1, 0), complex(-0.5, 0.5), 30)
+			p.Plane = &plane
+		}},
+		{name: "any plane", set: func(p *mandelbrot.RenderParams) {
+			p.Plane = &mandelbrot.Plane{
+				Origin: [4]float64{0.1, 0, -0.5, 0.2},
+				U:      [4]float64{0.3, 0.1, 0.9, 0},
+				V:      [4]float64{0, 0.6, 0.2, 0.7},
+			}
+		}},
+	} {
+		p := mandelbrot.DefaultRenderParams(64, 48)
+		tt.set(&p)
+		text, err := ImageText(p, "mandelbrot test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(pngtext.NewWriter(&buf, text...), mandelbrot.Render(p)); err != nil {
+			t.Fatal(err)
+		}
+
+		cfg, err := LoadImage(&buf)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := cfg.RenderParams()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got.Key() != p.Key() || got.View != p.View || got.Rotation != p.Rotation || got.Width != p.Width || got.Height != p.Height {
+			t.Errorf("%s: restored %s at %v rotated %v at %dx%d, want %s at %v rotated %v at %dx%d", tt.name,
+				got.Key(), got.View, got.Rotation, got.Width, got.Height, p.Key(), p.View, p.Rotation, p.Width, p.Height)
+		}
+		if got.Palette.Mode() != p.Palette.Mode() || got.Palette.Offset() != p.Palette.Offset() {
+			t.Errorf("%s: restored palette %v offset %v, want %v offset %v", tt.name,
+				got.Palette.Mode(), got.Palette.Offset(), p.Palette.Mode(), p.Palette.Offset())
+		}
+		if got.Lighting != p.Lighting || got.Coloring != p.Coloring || got.Contours != p.Contours {
+			t.Errorf("%s: restored shading %+v %+v %+v, want %+v %+v %+v", tt.name,
+				got.Lighting, got.Coloring, got.Contours, p.Lighting, p.Coloring, p.Contours)
+		}
+		if !bytes.Equal(mandelbrot.Render(got).Pix, mandelbrot.Render(p).Pix) {
+			t.Errorf("%s: the restored view renders differently", tt.name)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadImage(&buf); !errors.Is(err, ErrNoImageView) {
+		t.Errorf("LoadImage of a PNG without a view returned %v, want %v", err, ErrNoImageView)
+	}
+}
@@ -0,0 +1,454 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// View holds the optional starting location. Anything left unset falls back
+// to the defaults of the chosen fractal.
+type View struct {
+	Center     *Complex `json:"center,omitempty" yaml:"center,omitempty"`
+	Scale      float64  `json:"scale,omitempty" yaml:"scale,omitempty"`
+	Iterations uint64   `json:"iterations,omitempty" yaml:"iterations,omitempty"`
+	Exponent   *Complex `json:"exponent,omitempty" yaml:"exponent,omitempty"`
+	StartingZ  *Complex `json:"starting-z,omitempty" yaml:"starting-z,omitempty"`
+	StartingC  *Complex `json:"starting-c,omitempty" yaml:"starting-c,omitempty"`
+	Julia      bool     `json:"julia,omitempty" yaml:"julia,omitempty"`
+	// JuliaMethod is escape-time or inverse
+	JuliaMethod string `json:"julia-method,omitempty" yaml:"julia-method,omitempty"`
+	// Hybrid is the sequence of formulas of the hybrid fractal, like
+	// "mandelbrot,burning-ship,mandelbrot^3"
+	Hybrid string `json:"hybrid,omitempty" yaml:"hybrid,omitempty"`
+	// Palette is the name of the palette, see mandelbrot.PaletteNames
+	Palette string `json:"palette,omitempty" yaml:"palette,omitempty"`
+	// PaletteOffset turns the palette by a fraction of a cycle
+	PaletteOffset float64 `json:"palette-offset,omitempty" yaml:"palette-offset,omitempty"`
+	// Rotation turns the view counterclockwise, in degrees
+	Rotation float64 `json:"rotation,omitempty" yaml:"rotation,omitempty"`
+	// Parameters sets the fractal's parameters by name, like bailout
+	Parameters map[string]float64 `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Lighting   *Lighting          `json:"lighting,omitempty" yaml:"lighting,omitempty"`
+	Coloring   *Coloring          `json:"coloring,omitempty" yaml:"coloring,omitempty"`
+	Contours   *Contours          `json:"contours,omitempty" yaml:"contours,omitempty"`
+	// Plane shows any slice through the space of starting z and c instead
+	// of the Mandelbrot or Julia plane
+	Plane *Plane `json:"plane,omitempty" yaml:"plane,omitempty"`
+}
+
+// Plane is a slice through the space of starting z and c, see
+// mandelbrot.Plane. The origin defaults to 0.
+type Plane struct {
+	Origin *Vector `json:"origin,omitempty" yaml:"origin,omitempty"`
+	U      *Vector `json:"u,omitempty" yaml:"u,omitempty"`
+	V      *Vector `json:"v,omitempty" yaml:"v,omitempty"`
+}
+
+// Vector is a point of the space of starting z and c written like
+// "z.re,z.im,c.re,c.im" in config files and flags
+type Vector [4]float64
+
+func (v Vector) MarshalText() ([]byte, error) {
+	return []byte(mandelbrot.FormatVector(v)), nil
+}
+
+func (v *Vector) UnmarshalText(text []byte) error {
+	parsed, err := mandelbrot.ParseVector(string(text))
+	if err != nil {
+		return err
+	}
+	*v = parsed
+	return nil
+}
+
+// Lighting is the relief lighting of a view, see mandelbrot.Lighting
+type Lighting struct {
+	Enabled   bool    `json:"enabled" yaml:"enabled"`
+	Azimuth   float64 `json:"azimuth" yaml:"azimuth"`
+	Elevation float64 `json:"elevation" yaml:"elevation"`
+	Height    float64 `json:"height" yaml:"height"`
+	Ambient   float64 `json:"ambient" yaml:"ambient"`
+	Specular  float64 `json:"specular" yaml:"specular"`
+}
+
+// Coloring is how escaping points are colored, see mandelbrot.Coloring
+type Coloring struct {
+	// Method is iterations, stripe, triangle or curvature
+	Method        string  `json:"method" yaml:"method"`
+	StripeDensity float64 `json:"stripe-density" yaml:"stripe-density"`
+	Skip          int     `json:"skip" yaml:"skip"`
+}
+
+// Contours are the equipotentials and field lines drawn over a view, see
+// mandelbrot.Contours
+type Contours struct {
+	Equipotentials       bool    `json:"equipotentials" yaml:"equipotentials"`
+	FieldLines           bool    `json:"field-lines" yaml:"field-lines"`
+	EquipotentialDensity float64 `json:"equipotential-density" yaml:"equipotential-density"`
+	FieldLineDensity     float64 `json:"field-line-density" yaml:"field-line-density"`
+	Color                RGBA    `json:"color" yaml:"color"`
+}
+
+// RGBA is a color written like "#ffffffc0" in config files
+type RGBA [4]byte
+
+func (c RGBA) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("#%02x%02x%02x%02x", c[0], c[1], c[2], c[3])), nil
+}
+
+func (c *RGBA) UnmarshalText(text []byte) error {
+	s, ok := strings.CutPrefix(string(text), "#")
+	if !ok || len(s) != 8 {
+		return fmt.Errorf("invalid color %q, want #rrggbbaa", text)
+	}
+	v, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return fmt.Errorf("invalid color %q: %w", text, err)
+	}
+	*c = RGBA{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+	return nil
+}
+
+// Complex is a complex number written like "-0.75+0.1i" in config files
+// and flags
+type Complex complex128
+
+func (c Complex) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatComplex(complex128(c), 'g', -1, 128)), nil
+}
+
+func (c *Complex) UnmarshalText(text []byte) error {
+	v, err := strconv.ParseComplex(string(text), 128)
+	if err != nil {
+		return fmt.Errorf("invalid complex number %q: %w", text, err)
+	}
+	*c = Complex(v)
+	return nil
+}
+
+// RenderParams builds the params of the configured view
+func (c *Config) RenderParams() (mandelbrot.RenderParams, error) {
+	p := mandelbrot.DefaultRenderParams(int(c.Width), int(c.Height))
+
+	fractal, ok := mandelbrot.LookupFractal(c.Fractal)
+	if !ok {
+		return p, ErrInvalidFractal
+	}
+	p.Fractal = fractal
+	p.Params = mandelbrot.DefaultParams(fractal)
+	p.View = fractal.DefaultView()
+
+	if c.View.Center != nil {
+		p.View.Center = complex128(*c.View.Center)
+	}
+	if c.View.Scale != 0 {
+		p.View.Scale = c.View.Scale
+	}
+	if c.View.Iterations != 0 {
+		p.MaxIterations = c.View.Iterations
+	}
+	if c.View.Exponent != nil {
+		p.Params.Exponent = complex128(*c.View.Exponent)
+	}
+	if c.View.StartingZ != nil {
+		p.StartingZ = complex128(*c.View.StartingZ)
+	}
+	if c.View.StartingC != nil {
+		p.StartingC = complex128(*c.View.StartingC)
+	}
+	if c.View.Hybrid != "" && fractal.Name() == mandelbrot.FractalHybrid {
+		steps, err := mandelbrot.ParseHybrid(c.View.Hybrid)
+		if err != nil {
+			return p, fmt.Errorf("%w: %w", ErrInvalidHybrid, err)
+		}
+		p.Params.Hybrid = steps
+	}
+	if c.View.Palette != "" {
+		mode, ok := mandelbrot.LookupPaletteMode(c.View.Palette)
+		if !ok {
+			return p, ErrInvalidPalette
+		}
+		p.Palette = mandelbrot.NewPalette(mode)
+	}
+	if c.View.PaletteOffset != 0 {
+		p.Palette = p.Palette.WithOffset(c.View.PaletteOffset)
+	}
+	p.Rotation = c.View.Rotation
+	for name, value := range c.View.Parameters {
+		i, err := mandelbrot.ParameterIndex(fractal, name)
+		if err != nil {
+			return p, fmt.Errorf("%w: %w", ErrInvalidParameter, err)
+		}
+		p.Params.Values[i] = value
+	}
+	if l := c.View.Lighting; l != nil {
+		p.Lighting = mandelbrot.Lighting{
+			Enabled:   l.Enabled,
+			Azimuth:   l.Azimuth,
+			Elevation: l.Elevation,
+			Height:    l.Height,
+			Ambient:   l.Ambient,
+			Specular:  l.Specular,
+		}
+	}
+	if coloring := c.View.Coloring; coloring != nil {
+		p.Coloring = mandelbrot.Coloring{
+			Method:        mandelbrot.ColoringMethod(coloring.Method),
+			StripeDensity: coloring.StripeDensity,
+			Skip:          coloring.Skip,
+		}
+		if !slices.Contains(mandelbrot.ColoringMethods(), p.Coloring.Method) {
+			return p, ErrInvalidColoring
+		}
+	}
+	if contours := c.View.Contours; contours != nil {
+		p.Contours = mandelbrot.Contours{
+			Equipotentials:       contours.Equipotentials,
+			FieldLines:           contours.FieldLines,
+			EquipotentialDensity: contours.EquipotentialDensity,
+			FieldLineDensity:     contours.FieldLineDensity,
+			Color:                contours.Color,
+		}
+	}
+	if plane := c.View.Plane; plane != nil {
+		if plane.U == nil || plane.V == nil {
+			return p, ErrInvalidPlane
+		}
+		p.Plane = &mandelbrot.Plane{U: *plane.U, V: *plane.V}
+		if plane.Origin != nil {
+			p.Plane.Origin = *plane.Origin
+		}
+	}
+	p.Julia = c.View.Julia
+	switch method := mandelbrot.JuliaMethod(c.View.JuliaMethod); method {
+	case "":
+	case mandelbrot.JuliaMethodEscapeTime, mandelbrot.JuliaMethodInverse:
+		p.JuliaMethod = method
+	default:
+		return p, ErrInvalidJuliaMethod
+	}
+
+	return p, nil
+}
+
+func registerViewFlags(cmd *cobra.Command) {
+	cmd.Flags().String(ViewCenterKey, "", "Center of the view, like -0.75+0.1i")
+	cmd.Flags().Float64(ViewScaleKey, 0, "Scale of the view, 1 showing the whole fractal")
+	cmd.Flags().Uint64(ViewIterationsKey, 0, "Maximum iterations")
+	cmd.Flags().String(ViewExponentKey, "", "Exponent of the formula, like 2 or 3+0.5i")
+	cmd.Flags().String(ViewStartingZKey, "", "Starting z of the orbit")
+	cmd.Flags().String(ViewStartingCKey, "", "c used in Julia mode")
+	cmd.Flags().Bool(ViewJuliaKey, false, "Render the Julia set of the starting c")
+	cmd.Flags().String(ViewJuliaMethodKey, string(mandelbrot.JuliaMethodEscapeTime), "How Julia sets are drawn (escape-time, inverse)")
+	cmd.Flags().String(ViewPaletteKey, "", fmt.Sprintf("Palette (%s)", strings.Join(mandelbrot.PaletteNames(), ", ")))
+	cmd.Flags().Float64(ViewPaletteOffsetKey, 0, "Turn the palette by a fraction of a cycle")
+	cmd.Flags().Float64(ViewRotationKey, 0, "Rotation of the view in degrees, counterclockwise")
+	cmd.Flags().String(ViewPlaneOriginKey, "", "Origin of a plane through starting z and c to show, like 0,0,-0.5,0 for z.re,z.im,c.re,c.im")
+	cmd.Flags().String(ViewPlaneUKey, "", "Direction of the plane along the view's real axis, like 0,0,1,0")
+	cmd.Flags().String(ViewPlaneVKey, "", "Direction of the plane along the view's imaginary axis, like 0,0,0,1")
+	cmd.Flags().String(ViewHybridKey, "", "Formulas the hybrid fractal applies in turn, like mandelbrot,burning-ship,mandelbrot^3")
+}
+
+func overrideViewFlags(view *View, cmd *cobra.Command) error {
+	complexFlags := []struct {
+		key   string
+		value **Complex
+	}{
+		{ViewCenterKey, &view.Center},
+		{ViewExponentKey, &view.Exponent},
+		{ViewStartingZKey, &view.StartingZ},
+		{ViewStartingCKey, &view.StartingC},
+	}
+	for _, f := range complexFlags {
+		if !cmd.Flags().Changed(f.key) {
+			continue
+		}
+		s, err := cmd.Flags().GetString(f.key)
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", f.key, err)
+		}
+		var c Complex
+		if err := c.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f.key, err)
+		}
+		*f.value = &c
+	}
+
+	vectorFlags := []struct {
+		key   string
+		value func(*Plane) **Vector
+	}{
+		{ViewPlaneOriginKey, func(p *Plane) **Vector { return &p.Origin }},
+		{ViewPlaneUKey, func(p *Plane) **Vector { return &p.U }},
+		{ViewPlaneVKey, func(p *Plane) **Vector { return &p.V }},
+	}
+	for _, f := range vectorFlags {
+		if !cmd.Flags().Changed(f.key) {
+			continue
+		}
+		s, err := cmd.Flags().GetString(f.key)
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", f.key, err)
+		}
+		var v Vector
+		if err := v.UnmarshalText([]byte(s)); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", f.key, err)
+		}
+		if view.Plane == nil {
+			view.Plane = &Plane{}
+		}
+		*f.value(view.Plane) = &v
+	}
+
+	floatFlags := []struct {
+		key   string
+		value *float64
+	}{
+		{ViewScaleKey, &view.Scale},
+		{ViewPaletteOffsetKey, &view.PaletteOffset},
+		{ViewRotationKey, &view.Rotation},
+	}
+	for _, f := range floatFlags {
+		if !cmd.Flags().Changed(f.key) {
+			continue
+		}
+		v, err := cmd.Flags().GetFloat64(f.key)
+		if err != nil {
+			return fmt.Errorf("failed to get %s: %w", f.key, err)
+		}
+		*f.value = v
+	}
+
+	if cmd.Flags().Changed(ViewIterationsKey) {
+		iterations, err := cmd.Flags().GetUint64(ViewIterationsKey)
+		if err != nil {
+			return fmt.Errorf("failed to get iterations: %w", err)
+		}
+		view.Iterations = iterations
+	}
+
+	if cmd.Flags().Changed(ViewJuliaKey) {
+		julia, err := cmd.Flags().GetBool(ViewJuliaKey)
+		if err != nil {
+			return fmt.Errorf("failed to get julia: %w", err)
+		}
+		view.Julia = julia
+	}
+
+	if cmd.Flags().Changed(ViewPaletteKey) {
+		palette, err := cmd.Flags().GetString(ViewPaletteKey)
+		if err != nil {
+			return fmt.Errorf("failed to get palette: %w", err)
+		}
+		view.Palette = palette
+	}
+
+	if cmd.Flags().Changed(ViewHybridKey) {
+		hybrid, err := cmd.Flags().GetString(ViewHybridKey)
+		if err != nil {
+			return fmt.Errorf("failed to get hybrid: %w", err)
+		}
+		view.Hybrid = hybrid
+	}
+
+	if cmd.Flags().Changed(ViewJuliaMethodKey) {
+		method, err := cmd.Flags().GetString(ViewJuliaMethodKey)
+		if err != nil {
+			return fmt.Errorf("failed to get julia method: %w", err)
+		}
+		view.JuliaMethod = method
+	}
+
+	return nil
+}
+
+// savedView is the part of a config file that describes what is on screen
+type savedView struct {
+	Width   uint   `json:"width" yaml:"width"`
+	Height  uint   `json:"height" yaml:"height"`
+	Fractal string `json:"fractal" yaml:"fractal"`
+	View    View   `json:"view" yaml:"view"`
+}
+
+// MarshalView returns the view of p as a config file, holding everything
+// the render depends on
+func MarshalView(p mandelbrot.RenderParams) ([]byte, error) {
+	center := Complex(p.View.Center)
+	exponent := Complex(p.Params.Exponent)
+	startingZ := Complex(p.StartingZ)
+	startingC := Complex(p.StartingC)
+	saved := savedView{
+		Width:   uint(p.Width),
+		Height:  uint(p.Height),
+		Fractal: p.Fractal.Name(),
+		View: View{
+			Center:        &center,
+			Scale:         p.View.Scale,
+			Iterations:    p.MaxIterations,
+			Exponent:      &exponent,
+			StartingZ:     &startingZ,
+			StartingC:     &startingC,
+			Julia:         p.Julia,
+			JuliaMethod:   string(p.JuliaMethod),
+			Hybrid:        mandelbrot.FormatHybrid(p.Params.Hybrid),
+			Palette:       p.Palette.Mode().String(),
+			PaletteOffset: p.Palette.Offset(),
+			Rotation:      p.Rotation,
+			Lighting: &Lighting{
+				Enabled:   p.Lighting.Enabled,
+				Azimuth:   p.Lighting.Azimuth,
+				Elevation: p.Lighting.Elevation,
+				Height:    p.Lighting.Height,
+				Ambient:   p.Lighting.Ambient,
+				Specular:  p.Lighting.Specular,
+			},
+			Coloring: &Coloring{
+				Method:        string(p.Coloring.Method),
+				StripeDensity: p.Coloring.StripeDensity,
+				Skip:          p.Coloring.Skip,
+			},
+			Contours: &Contours{
+				Equipotentials:       p.Contours.Equipotentials,
+				FieldLines:           p.Contours.FieldLines,
+				EquipotentialDensity: p.Contours.EquipotentialDensity,
+				FieldLineDensity:     p.Contours.FieldLineDensity,
+				Color:                p.Contours.Color,
+			},
+		},
+	}
+	if p.Plane != nil {
+		origin, u, v := Vector(p.Plane.Origin), Vector(p.Plane.U), Vector(p.Plane.V)
+		saved.View.Plane = &Plane{Origin: &origin, U: &u, V: &v}
+	}
+	if schema := p.Fractal.Parameters(); len(schema) > 0 {
+		saved.View.Parameters = make(map[string]float64, len(schema))
+		for i, param := range schema {
+			saved.View.Parameters[param.Name] = p.Params.Values[i]
+		}
+	}
+	data, err := yaml.Marshal(&saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal view: %w", err)
+	}
+	return data, nil
+}
+
+// SaveView writes the view of p to a config file, which can be loaded again
+// with --config
+func SaveView(path string, p mandelbrot.RenderParams) error {
+	data, err := MarshalView(p)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write view: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package export
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+type Field string
+
+const (
+	// FieldIterations uses the smoothed escape iteration count
+	FieldIterations Field = "iterations"
+	// FieldDistance uses the distance estimate, rising towards the set
+	FieldDistance Field = "distance"
+)
+
+const (
+	// distanceOctaves is how many doublings of the distance, in pixels, the
+	// distance field spans from the top of the relief to the floor
+	distanceOctaves = 8
+	// distanceBailout is the least escape radius used for the distance field.
+	// Distance estimates are only accurate once |z| is large.
+	distanceBailout = 256
+)
+
+// Heightfield is a grid of heights between 0 and 1, row by row from the top
+// left, with the set itself at the top
+type Heightfield struct {
+	Width, Height int
+	Heights       []float64
+}
+
+// NewHeightfield renders the view and turns the chosen field into heights
+func NewHeightfield(p mandelbrot.RenderParams, field Field) (*Heightfield, error) {
+	switch field {
+	case FieldIterations:
+	case FieldDistance:
+		p = distanceParams(p)
+	default:
+		return nil, fmt.Errorf("unknown field %q", field)
+	}
+
+	samples := make([]mandelbrot.Sample, p.Width*p.Height)
+	mandelbrot.RenderInto(p, image.NewRGBA(image.Rect(0, 0, p.Width, p.Height)), samples)
+
+	hf := &Heightfield{
+		Width:   p.Width,
+		Height:  p.Height,
+		Heights: make([]float64, len(samples)),
+	}
+	switch field {
+	case FieldIterations:
+		hf.fromIterations(&p, samples)
+	case FieldDistance:
+		hf.fromDistance(&p, samples)
+	}
+	return hf, nil
+}
+
+// distanceParams tracks the derivative and raises the bailout so distance
+// estimates can be read from the samples
+func distanceParams(p mandelbrot.RenderParams) mandelbrot.RenderParams {
+	p.TrackDerivative = true
+	if i, err := mandelbrot.ParameterIndex(p.Fractal, "bailout"); err == nil && p.Params.Values[i] < distanceBailout {
+		p.Params.Values = append([]float64(nil), p.Params.Values...)
+		p.Params.Values[i] = distanceBailout
+	}
+	return p
+}
+
+func (hf *Heightfield) fromIterations(p *mandelbrot.RenderParams, samples []mandelbrot.Sample) {
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for i, s := range samples {
+		if !p.Escaped(s) {
+			continue
+		}
+		v := p.SmoothIterations(s)
+		hf.Heights[i] = v
+		lowest = min(lowest, v)
+		highest = max(highest, v)
+	}
+	span := highest - lowest
+	for i, s := range samples {
+		switch {
+		case !p.Escaped(s):
+			hf.Heights[i] = 1
+		case span > 0:
+			hf.Heights[i] = (hf.Heights[i] - lowest) / span
+		default:
+			hf.Heights[i] = 0
+		}
+	}
+}
+
+func (hf *Heightfield) fromDistance(p *mandelbrot.RenderParams, samples []mandelbrot.Sample) {
+	pixel := min(p.PixelSize())
+	for i, s := range samples {
+		if !p.Escaped(s) {
+			hf.Heights[i] = 1
+			continue
+		}
+		octaves := math.Log2(1 + p.Distance(s)/pixel)
+		hf.Heights[i] = 1 - min(1, octaves/distanceOctaves)
+	}
+}
+
+func (hf *Heightfield) At(x, y int) float64 {
+	return hf.Heights[y*hf.Width+x]
+}
+
+// WritePNG writes the heights as a 16 bit grayscale PNG
+func (hf *Heightfield) WritePNG(w io.Writer) error {
+	img := image.NewGray16(image.Rect(0, 0, hf.Width, hf.Height))
+	for y := range hf.Height {
+		for x := range hf.Width {
+			img.SetGray16(x, y, color.Gray16{Y: uint16(math.Round(hf.At(x, y) * math.MaxUint16))})
+		}
+	}
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode heightmap: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+type MeshOptions struct {
+	// HeightScale is the height of the tallest point above the base
+	HeightScale float64
+	// Base is the thickness of the solid slab under the relief
+	Base float64
+	// Decimate keeps every nth sample in each direction
+	Decimate int
+}
+
+// Mesh is a closed triangle mesh: the relief on top, walls around it and a
+// flat bottom, so it can be sliced for printing as is. One pixel of the
+// heightfield is one unit.
+type Mesh struct {
+	Vertices  [][3]float32
+	Triangles [][3]uint32
+}
+
+func NewMesh(hf *Heightfield, opts MeshOptions) (*Mesh, error) {
+	if opts.Decimate < 1 {
+		return nil, fmt.Errorf("invalid decimation %d", opts.Decimate)
+	}
+	if hf.Width < 2 || hf.Height < 2 {
+		return nil, fmt.Errorf("heightfield of %dx%d is too small for a mesh", hf.Width, hf.Height)
+	}
+
+	cols := (hf.Width-1)/opts.Decimate + 1
+	rows := (hf.Height-1)/opts.Decimate + 1
+	mesh := &Mesh{}
+
+	// Rows run down the image while y runs up, so the relief seen from
+	// above matches the image
+	top := func(i, j int) uint32 { return uint32(j*cols + i) }
+	for j := range rows {
+		for i := range cols {
+			x, y := min(i*opts.Decimate, hf.Width-1), min(j*opts.Decimate, hf.Height-1)
+			mesh.Vertices = append(mesh.Vertices, [3]float32{
+				float32(x),
+				float32(hf.Height - 1 - y),
+				float32(opts.Base + hf.At(x, y)*opts.HeightScale),
+			})
+		}
+	}
+	for j := range rows - 1 {
+		for i := range cols - 1 {
+			a, b := top(i, j), top(i+1, j)
+			c, d := top(i, j+1), top(i+1, j+1)
+			mesh.Triangles = append(mesh.Triangles, [3]uint32{c, d, b}, [3]uint32{c, b, a})
+		}
+	}
+
+	// Walk the border counter clockwise seen from above
+	var border []uint32
+	for i := range cols {
+		border = append(border, top(i, rows-1))
+	}
+	for j := rows - 2; j >= 0; j-- {
+		border = append(border, top(cols-1, j))
+	}
+	for i := cols - 2; i >= 0; i-- {
+		border = append(border, top(i, 0))
+	}
+	for j := 1; j < rows-1; j++ {
+		border = append(border, top(0, j))
+	}
+
+	bottom := make([]uint32, len(border))
+	for k, t := range border {
+		v := mesh.Vertices[t]
+		bottom[k] = uint32(len(mesh.Vertices))
+		mesh.Vertices = append(mesh.Vertices, [3]float32{v[0], v[1], 0})
+	}
+	center := uint32(len(mesh.Vertices))
+	mesh.Vertices = append(mesh.Vertices, [3]float32{float32(hf.Width-1) / 2, float32(hf.Height-1) / 2, 0})
+
+	for k := range border {
+		next := (k + 1) % len(border)
+		tp, tq := border[k], border[next]
+		bp, bq := bottom[k], bottom[next]
+		mesh.Triangles = append(mesh.Triangles,
+			[3]uint32{bp, bq, tq},
+			[3]uint32{bp, tq, tp},
+			[3]uint32{center, bq, bp},
+		)
+	}
+
+	return mesh, nil
+}
+
+func (m *Mesh) normal(t [3]uint32) [3]float32 {
+	a, b, c := m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]
+	u := [3]float64{float64(b[0] - a[0]), float64(b[1] - a[1]), float64(b[2] - a[2])}
+	v := [3]float64{float64(c[0] - a[0]), float64(c[1] - a[1]), float64(c[2] - a[2])}
+	n := [3]float64{u[1]*v[2] - u[2]*v[1], u[2]*v[0] - u[0]*v[2], u[0]*v[1] - u[1]*v[0]}
+	length := math.Sqrt(n[0]*n[0] + n[1]*n[1] + n[2]*n[2])
+	if length == 0 {
+		return [3]float32{}
+	}
+	return [3]float32{float32(n[0] / length), float32(n[1] / length), float32(n[2] / length)}
+}
+
+// WriteSTL writes the mesh as binary STL
+func (m *Mesh) WriteSTL(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 80)
+	copy(header, "mandelbrot relief")
+	if _, err := bw.Write(header); err != nil {
+		return fmt.Errorf("failed to write STL header: %w", err)
+	}
+	if err := binary.Write(bw, binary.LittleEndian, uint32(len(m.Triangles))); err != nil {
+		return fmt.Errorf("failed to write STL triangle count: %w", err)
+	}
+	for _, t := range m.Triangles {
+		facet := struct {
+			Normal    [3]float32
+			Vertices  [3][3]float32
+			Attribute uint16
+		}{
+			Normal:   m.normal(t),
+			Vertices: [3][3]float32{m.Vertices[t[0]], m.Vertices[t[1]], m.Vertices[t[2]]},
+		}
+		if err := binary.Write(bw, binary.LittleEndian, &facet); err != nil {
+			return fmt.Errorf("failed to write STL facet: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write STL: %w", err)
+	}
+	return nil
+}
+
+// WriteOBJ writes the mesh as Wavefront OBJ
+func (m *Mesh) WriteOBJ(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# mandelbrot relief")
+	for _, v := range m.Vertices {
+		fmt.Fprintf(bw, "v %g %g %g\n", v[0], v[1], v[2])
+	}
+	// OBJ indices start at 1
+	for _, t := range m.Triangles {
+		fmt.Fprintf(bw, "f %d %d %d\n", t[0]+1, t[1]+1, t[2]+1)
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write OBJ: %w", err)
+	}
+	return nil
+}
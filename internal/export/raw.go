@@ -0,0 +1,182 @@
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+// RawFieldNames lists the fields of a raw export in the order each pixel
+// holds them: the smooth iteration count, which is the iteration limit for
+// points that never escape, the distance estimate, 0 inside the set, and the
+// real and imaginary parts of the final z.
+func RawFieldNames() []string {
+	return []string{"iterations", "distance", "z-real", "z-imag"}
+}
+
+// Raw holds the fields behind each pixel, row by row from the top left
+type Raw struct {
+	Width, Height int
+	Values        []float64
+}
+
+// NewRaw renders the view and keeps the fields of every sample
+func NewRaw(p mandelbrot.RenderParams) *Raw {
+	p = distanceParams(p)
+	samples := make([]mandelbrot.Sample, p.Width*p.Height)
+	mandelbrot.RenderInto(p, image.NewRGBA(image.Rect(0, 0, p.Width, p.Height)), samples)
+
+	fields := len(RawFieldNames())
+	raw := &Raw{
+		Width:  p.Width,
+		Height: p.Height,
+		Values: make([]float64, len(samples)*fields),
+	}
+	for i, s := range samples {
+		v := raw.Values[i*fields : (i+1)*fields]
+		v[0] = p.SmoothIterations(s)
+		v[1] = p.Distance(s)
+		v[2] = real(s.Z)
+		v[3] = imag(s.Z)
+	}
+	return raw
+}
+
+// WriteNPY writes the fields as a NumPy array of float64 shaped
+// (height, width, fields)
+func (r *Raw) WriteNPY(w io.Writer) error {
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%d, %d, %d), }",
+		r.Height, r.Width, len(RawFieldNames()))
+	// The magic, version and length take 10 bytes, and the header ends in a
+	// newline with everything padded to a multiple of 64 bytes
+	padding := 64 - (10+len(header)+1)%64
+	header += strings.Repeat(" ", padding%64) + "\n"
+
+	bw := bufio.NewWriter(w)
+	prefix := []byte("\x93NUMPY\x01\x00")
+	prefix = binary.LittleEndian.AppendUint16(prefix, uint16(len(header)))
+	if _, err := bw.Write(prefix); err != nil {
+		return fmt.Errorf("failed to write NPY header: %w", err)
+	}
+	if _, err := bw.WriteString(header); err != nil {
+		return fmt.Errorf("failed to write NPY header: %w", err)
+	}
+	var b [8]byte
+	for _, v := range r.Values {
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+		if _, err := bw.Write(b[:]); err != nil {
+			return fmt.Errorf("failed to write NPY data: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush NPY: %w", err)
+	}
+	return nil
+}
+
+// WriteTIFF writes the fields as a TIFF of 32 bit floats, one sample per
+// field. The first is read as gray and the rest as extra samples.
+func (r *Raw) WriteTIFF(w io.Writer) error {
+	const (
+		short = 3
+		long  = 4
+	)
+	fields := len(RawFieldNames())
+	dataSize := uint64(r.Width) * uint64(r.Height) * uint64(fields) * 4
+
+	repeat := func(v uint32, n int) []uint32 {
+		values := make([]uint32, n)
+		for i := range values {
+			values[i] = v
+		}
+		return values
+	}
+	entries := []struct {
+		tag, kind uint16
+		values    []uint32
+	}{
+		{256, long, []uint32{uint32(r.Width)}},
+		{257, long, []uint32{uint32(r.Height)}},
+		{258, short, repeat(32, fields)}, // bits per sample
+		{259, short, []uint32{1}},        // no compression
+		{262, short, []uint32{1}},        // black is zero
+		{273, long, []uint32{0}},         // strip offset, filled in below
+		{277, short, []uint32{uint32(fields)}},
+		{278, long, []uint32{uint32(r.Height)}},
+		{279, long, []uint32{uint32(dataSize)}},
+		{284, short, []uint32{1}},         // samples interleaved
+		{338, short, repeat(0, fields-1)}, // unspecified extra samples
+		{339, short, repeat(3, fields)},   // IEEE floats
+	}
+
+	// The directory follows the header, then values too large for their
+	// entries, then the single strip of pixels
+	ifdSize := 2 + 12*len(entries) + 4
+	next := uint32(8 + ifdSize)
+	var values []byte
+	ifd := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	encode := func(kind uint16, vs []uint32) []byte {
+		var b []byte
+		for _, v := range vs {
+			if kind == short {
+				b = binary.LittleEndian.AppendUint16(b, uint16(v))
+			} else {
+				b = binary.LittleEndian.AppendUint32(b, v)
+			}
+		}
+		return b
+	}
+	extra := 0
+	for _, e := range entries {
+		if size := len(encode(e.kind, e.values)); size > 4 {
+			extra += size + size%2
+		}
+	}
+	dataOffset := uint64(next) + uint64(extra)
+	if dataOffset+dataSize > math.MaxUint32 {
+		return fmt.Errorf("a %dx%d float TIFF is larger than the 4 GiB TIFF can address, use NPY", r.Width, r.Height)
+	}
+	entries[5].values[0] = uint32(dataOffset)
+	for _, e := range entries {
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.tag)
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.kind)
+		ifd = binary.LittleEndian.AppendUint32(ifd, uint32(len(e.values)))
+		data := encode(e.kind, e.values)
+		if len(data) <= 4 {
+			ifd = append(ifd, data...)
+			ifd = append(ifd, make([]byte, 4-len(data))...)
+			continue
+		}
+		ifd = binary.LittleEndian.AppendUint32(ifd, next)
+		// Values start on word boundaries
+		data = append(data, make([]byte, len(data)%2)...)
+		values = append(values, data...)
+		next += uint32(len(data))
+	}
+	ifd = binary.LittleEndian.AppendUint32(ifd, 0) // no further directories
+
+	bw := bufio.NewWriter(w)
+	header := binary.LittleEndian.AppendUint32([]byte{'I', 'I', 42, 0}, 8)
+	for _, b := range [][]byte{header, ifd, values} {
+		if _, err := bw.Write(b); err != nil {
+			return fmt.Errorf("failed to write TIFF header: %w", err)
+		}
+	}
+	var b [4]byte
+	for _, v := range r.Values {
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+		if _, err := bw.Write(b[:]); err != nil {
+			return fmt.Errorf("failed to write TIFF data: %w", err)
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to flush TIFF: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+// Sizes whose shapes are written with different lengths, so the NPY header
+// padding varies
+//
+//nolint:golint,gochecknoglobals
+var rawSizes = []struct{ width, height int }{
+	{1, 1},
+	{3, 2},
+	{10, 7},
+	{123, 45},
+}
+
+func TestWriteNPY(t *testing.T) {
+	for _, tt := range rawSizes {
+		r := NewRaw(mandelbrot.DefaultRenderParams(tt.width, tt.height))
+		var buf bytes.Buffer
+		if err := r.WriteNPY(&buf); err != nil {
+			t.Fatal(err)
+		}
+		b := buf.Bytes()
+		if !bytes.HasPrefix(b, []byte("\x93NUMPY\x01\x00")) {
+			t.Fatalf("%dx%d: NPY starts with %q", tt.width, tt.height, b[:8])
+		}
+		dataStart := 10 + int(binary.LittleEndian.Uint16(b[8:]))
+		if dataStart%64 != 0 {
+			t.Errorf("%dx%d: data starts at %d, which isn't a multiple of 64", tt.width, tt.height, dataStart)
+		}
+		header := string(b[10:dataStart])
+		shape := fmt.Sprintf("'shape': (%d, %d, %d)", tt.height, tt.width, len(RawFieldNames()))
+		dict := strings.TrimRight(header, " \n")
+		if !strings.HasSuffix(header, "\n") || !strings.HasPrefix(dict, "{'descr': '<f8'") ||
+			!strings.Contains(dict, shape) || !strings.HasSuffix(dict, "}") {
+			t.Errorf("%dx%d: header %q doesn't describe a %s array of little endian float64", tt.width, tt.height, header, shape)
+		}
+		data := b[dataStart:]
+		if len(data) != 8*len(r.Values) {
+			t.Fatalf("%dx%d: NPY holds %d bytes of data, want %d", tt.width, tt.height, len(data), 8*len(r.Values))
+		}
+		for i, v := range r.Values {
+			if got := binary.LittleEndian.Uint64(data[8*i:]); got != math.Float64bits(v) {
+				t.Fatalf("%dx%d: value %d is %v, want %v", tt.width, tt.height, i, math.Float64frombits(got), v)
+			}
+		}
+	}
+}
+
+func TestWriteTIFF(t *testing.T) {
+	samples := uint32(len(RawFieldNames()))
+	for _, tt := range rawSizes {
+		r := NewRaw(mandelbrot.DefaultRenderParams(tt.width, tt.height))
+		var buf bytes.Buffer
+		if err := r.WriteTIFF(&buf); err != nil {
+			t.Fatal(err)
+		}
+		b := buf.Bytes()
+		if !bytes.HasPrefix(b, []byte{'I', 'I', 42, 0}) {
+			t.Fatalf("%dx%d: TIFF starts with %q", tt.width, tt.height, b[:4])
+		}
+
+		// Read every field of the directory, shorts and longs alike
+		ifd := binary.LittleEndian.Uint32(b[4:])
+		fields := map[uint16][]uint32{}
+		for i := range int(binary.LittleEndian.Uint16(b[ifd:])) {
+			e := b[int(ifd)+2+12*i:]
+			tag, kind, count := binary.LittleEndian.Uint16(e), binary.LittleEndian.Uint16(e[2:]), int(binary.LittleEndian.Uint32(e[4:]))
+			size := 2
+			if kind == 4 {
+				size = 4
+			}
+			values := e[8:12]
+			if count*size > 4 {
+				values = b[binary.LittleEndian.Uint32(e[8:]):]
+			}
+			for j := range count {
+				if size == 2 {
+					fields[tag] = append(fields[tag], uint32(binary.LittleEndian.Uint16(values[2*j:])))
+				} else {
+					fields[tag] = append(fields[tag], binary.LittleEndian.Uint32(values[4*j:]))
+				}
+			}
+		}
+		for _, field := range []struct {
+			tag   uint16
+			count uint32
+			value uint32
+		}{
+			{256, 1, uint32(tt.width)},
+			{257, 1, uint32(tt.height)},
+			{258, samples, 32}, // bits per sample
+			{277, 1, samples},
+			{338, samples - 1, 0}, // unspecified extra samples
+			{339, samples, 3},     // floating point
+		} {
+			got := fields[field.tag]
+			if uint32(len(got)) != field.count || (len(got) > 0 && (got[0] != field.value || got[len(got)-1] != field.value)) {
+				t.Errorf("%dx%d: tag %d is %v, want %d times %d", tt.width, tt.height, field.tag, got, field.count, field.value)
+			}
+		}
+
+		offset, count := fields[273][0], fields[279][0]
+		if int(count) != 4*len(r.Values) || int(offset+count) != len(b) {
+			t.Fatalf("%dx%d: strip of %d bytes at %d doesn't hold the %d values ending the file of %d bytes",
+				tt.width, tt.height, count, offset, len(r.Values), len(b))
+		}
+		for i, v := range r.Values {
+			if got := binary.LittleEndian.Uint32(b[int(offset)+4*i:]); got != math.Float32bits(float32(v)) {
+				t.Fatalf("%dx%d: value %d is %v, want %v", tt.width, tt.height, i, math.Float32frombits(got), float32(v))
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package game
+
+import "github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+
+// viewAnimationFrames is how many ticks flying to a view takes
+const viewAnimationFrames = 90
+
+// viewAnimation flies the explorer from one view to another, easing in and
+// out of mandelbrot.InterpolateView
+type viewAnimation struct {
+	from, to mandelbrot.View
+	frame    int
+}
+
+func newViewAnimation(from, to mandelbrot.View) *viewAnimation {
+	return &viewAnimation{from: from, to: to}
+}
+
+// Step advances the animation and returns the view to show, and whether the
+// animation has finished
+func (a *viewAnimation) Step() (mandelbrot.View, bool) {
+	a.frame++
+	if a.frame >= viewAnimationFrames {
+		return a.to, true
+	}
+	t := float64(a.frame) / viewAnimationFrames
+	// Smoothstep eases in and out of the flight
+	t = t * t * (3 - 2*t)
+	return mandelbrot.InterpolateView(a.from, a.to, t), false
+}
+
+// planeAnimationFrames is how many ticks turning between planes takes
+const planeAnimationFrames = 120
+
+// planeAnimation turns the plane of starting z and c being shown from one
+// angle to another, where 0 degrees is the Mandelbrot plane and 90 the Julia
+// plane
+type planeAnimation struct {
+	from, to float64
+	frame    int
+}
+
+func newPlaneAnimation(from, to float64) *planeAnimation {
+	return &planeAnimation{from: from, to: to}
+}
+
+// Step advances the animation and returns the angle to show, and whether
+// the animation has finished
+func (a *planeAnimation) Step() (float64, bool) {
+	a.frame++
+	if a.frame >= planeAnimationFrames {
+		return a.to, true
+	}
+	t := float64(a.frame) / planeAnimationFrames
+	t = t * t * (3 - 2*t)
+	return a.from + (a.to-a.from)*t, false
+}
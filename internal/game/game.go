@@ -2,12 +2,25 @@ package game
 
 import (
 	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"math/big"
+	"os"
+	"path"
+	"strings"
 
+	"github.com/USA-RedDragon/mandelbrot/internal/animation"
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
 	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
 	"github.com/USA-RedDragon/mandelbrot/internal/ui"
 	"github.com/ebitenui/ebitenui"
+	"github.com/ebitenui/ebitenui/input"
 	"github.com/ebitenui/ebitenui/widget"
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
 type Game struct {
@@ -16,9 +29,35 @@ type Game struct {
 	height     uint
 	ui         *ebitenui.UI
 	exit       bool
+	dragging   bool
+	dragX      int
+	dragY      int
+	preview    *juliaPreview
+	orbit      *orbitOverlay
+	rays       *rayOverlay
+	space      *spaceView
+	animation  *viewAnimation
+	// timeline is the keyframe timeline given on the command line, if any
+	timeline *timelinePreview
+	// planeAngle is the angle of the plane shown while it is between the
+	// Mandelbrot and Julia planes
+	planeAngle     float64
+	planeAnimation *planeAnimation
+	// planeTurned is set while the plane shown is the one at planeAngle,
+	// rather than one set by hand, so it follows the starting z and c
+	planeTurned bool
+	// minibrots were found in the view by the last search from the toolbar
+	minibrots      []mandelbrot.Nucleus
+	minibrotSearch *minibrotSearch
+	// misiurewicz is what is searched for near the cursor
+	misiurewicz misiurewiczSearch
+	toolbar     *ui.Toolbar
+	// software names the program in the images it saves
+	software string
 }
 
-func NewGame(width, height uint) (*Game, error) {
+func NewGame(cfg *config.Config, software string) (*Game, error) {
+	width, height := cfg.Width, cfg.Height
 	ebiten.SetWindowSize(int(width), int(height))
 	ebiten.SetWindowTitle("Fractal Explorer")
 	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
@@ -36,15 +75,45 @@ func NewGame(width, height uint) (*Game, error) {
 	}
 
 	game := &Game{
-		mandelbrot: mandelbrot.NewMandelbrot(int(width), int(height)),
-		width:      width,
-		height:     height,
-		ui:         eui,
-		exit:       false,
+		mandelbrot:     mandelbrot.NewMandelbrot(int(width), int(height)),
+		width:          width,
+		height:         height,
+		ui:             eui,
+		exit:           false,
+		preview:        newJuliaPreview(),
+		orbit:          &orbitOverlay{},
+		rays:           &rayOverlay{},
+		minibrotSearch: newMinibrotSearch(),
+		space:          newSpaceView(int(width), int(height)),
+		// M4,1 is the spiral center at -0.1011+0.9563i
+		misiurewicz: misiurewiczSearch{preperiod: 4, period: 1},
+		software:    software,
+	}
+	// Without the cache every pixel is computed for exactly where it is
+	if cfg.TileCache.Enabled {
+		tileCache, err := mandelbrot.NewTileCache(int(cfg.TileCache.Size), cfg.TileCache.Dir)
+		if err != nil {
+			return nil, fmt.Errorf("error creating tile cache: %w", err)
+		}
+		game.mandelbrot.SetTileCache(tileCache)
+	}
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return nil, fmt.Errorf("error reading view: %w", err)
+	}
+	game.mandelbrot.SetRenderParams(params)
+
+	if cfg.Timeline != "" {
+		timeline, err := animation.LoadTimeline(cfg.Timeline)
+		if err != nil {
+			return nil, fmt.Errorf("error loading timeline: %w", err)
+		}
+		game.timeline = newTimelinePreview(timeline, int(width), int(height))
+		game.timeline.Play(game.mandelbrot.GetRenderParams())
 	}
 
 	manager := NewUIManager(game)
-	ui.CreateToolbar(manager, eui, res)
+	game.toolbar = ui.CreateToolbar(manager, eui, res)
 
 	return game, nil
 }
@@ -55,8 +124,43 @@ func (g *Game) Update() error {
 	}
 
 	g.ui.Update()
+	g.minibrotSearch.Update()
+	if files := ebiten.DroppedFiles(); files != nil {
+		g.openDropped(files)
+	}
+	if g.space.Enabled() {
+		g.updateSpace()
+		return nil
+	}
+	if g.timeline != nil && !g.ui.HasFocus() && inpututil.IsKeyJustPressed(ebiten.KeyT) && !g.timeline.Enabled() {
+		g.timeline.Play(g.mandelbrot.GetRenderParams())
+	}
+	if g.timeline != nil && g.timeline.Enabled() {
+		g.updateTimeline()
+		return nil
+	}
+	if g.animation != nil {
+		view, done := g.animation.Step()
+		g.setView(view)
+		if done {
+			g.animation = nil
+		}
+	}
+	if g.planeAnimation != nil {
+		angle, done := g.planeAnimation.Step()
+		g.setPlaneAngle(angle)
+		if done {
+			g.planeAnimation = nil
+			g.toolbar.Refresh()
+		}
+	} else if g.planeTurned && g.mandelbrot.GetPlane() != nil {
+		// Follow edits to the starting z and c the plane passes through
+		g.setPlaneAngle(g.planeAngle)
+	}
+
 	_, wheelY := ebiten.Wheel()
 	if wheelY != 0 {
+		g.animation = nil
 		x, y := ebiten.CursorPosition()
 
 		desiredCursorPoint := g.mandelbrot.ScreenToViewport(x, y)
@@ -67,17 +171,299 @@ func (g *Game) Update() error {
 		g.mandelbrot.Center(g.mandelbrot.GetCenter() + (desiredCursorPoint - cursorPointAfterScale))
 	}
 
+	x, y := ebiten.CursorPosition()
+	g.preview.Update(g.mandelbrot, x, y, input.UIHovered)
+	// Holding shift traces the orbit of the point under the cursor
+	g.orbit.Update(g.mandelbrot, x, y, ebiten.IsKeyPressed(ebiten.KeyShift) && !input.UIHovered && !g.ui.HasFocus())
+	overPreview := g.preview.visible && image.Pt(x, y).In(g.preview.Rect(g.mandelbrot.Size()))
+	if (inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && overPreview) ||
+		(inpututil.IsKeyJustPressed(ebiten.KeyJ) && !g.ui.HasFocus()) {
+		g.preview.Promote(g.mandelbrot)
+		g.toolbar.Refresh()
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyN) && !g.ui.HasFocus() {
+		g.zoomToNucleus(x, y)
+	} else if inpututil.IsKeyJustPressed(ebiten.KeyM) && !g.ui.HasFocus() {
+		g.centerOnMisiurewicz(x, y)
+	} else if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && !input.UIHovered {
+		g.animation = nil
+		g.dragging = true
+		g.dragX, g.dragY = x, y
+	} else if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.dragging = false
+	}
+	if g.dragging && (x != g.dragX || y != g.dragY) {
+		// Whole-pixel drags let the mandelbrot reuse the pixels already on screen
+		delta := g.mandelbrot.ScreenToViewport(g.dragX, g.dragY) - g.mandelbrot.ScreenToViewport(x, y)
+		g.mandelbrot.Center(g.mandelbrot.GetCenter() + delta)
+		g.dragX, g.dragY = x, y
+	}
+
+	return nil
+}
+
+// updateSpace handles input in 3D mode, where dragging orbits the camera
+// and the wheel moves it closer or further. J/L, I/K and U/O rotate the
+// slice of a quaternion Julia set.
+func (g *Game) updateSpace() {
+	if _, wheelY := ebiten.Wheel(); wheelY != 0 {
+		g.space.Zoom(wheelY)
+	}
+	if g.space.mode == spaceModeQuaternionJulia && !g.ui.HasFocus() {
+		var rotation [3]float64
+		keys := [3][2]ebiten.Key{
+			{ebiten.KeyJ, ebiten.KeyL},
+			{ebiten.KeyK, ebiten.KeyI},
+			{ebiten.KeyU, ebiten.KeyO},
+		}
+		for plane, pair := range keys {
+			if ebiten.IsKeyPressed(pair[0]) {
+				rotation[plane] -= sliceRotateSpeed
+			}
+			if ebiten.IsKeyPressed(pair[1]) {
+				rotation[plane] += sliceRotateSpeed
+			}
+		}
+		if rotation != [3]float64{} {
+			g.space.RotateSlice(rotation[0], rotation[1], rotation[2])
+		}
+	}
+	x, y := ebiten.CursorPosition()
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && !input.UIHovered {
+		g.dragging = true
+		g.dragX, g.dragY = x, y
+	} else if !ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		g.dragging = false
+	}
+	if g.dragging && (x != g.dragX || y != g.dragY) {
+		g.space.Orbit(x-g.dragX, y-g.dragY)
+		g.dragX, g.dragY = x, y
+	}
+	g.space.Update(g.mandelbrot.GetStartingC())
+}
+
+// updateTimeline handles input while a timeline plays, where space pauses
+// and escape goes back to exploring
+func (g *Game) updateTimeline() {
+	if !g.ui.HasFocus() {
+		if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+			g.timeline.TogglePause()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.timeline.Stop()
+			return
+		}
+	}
+	g.timeline.Update()
+}
+
+// openDropped restores the view of the first image dropped on the window
+// that carries one
+func (g *Game) openDropped(files fs.FS) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		slog.Error("Failed to read dropped files", "error", err)
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(path.Ext(entry.Name()), ".png") {
+			continue
+		}
+		if err := g.openImage(files, entry.Name()); err != nil {
+			slog.Error("Failed to open image", "path", entry.Name(), "error", err)
+			continue
+		}
+		slog.Info("Opened image", "path", entry.Name())
+		return
+	}
+}
+
+// openImage restores the view an image was rendered from
+func (g *Game) openImage(files fs.FS, name string) error {
+	f, err := files.Open(name)
+	if err != nil {
+		return fmt.Errorf("failed to open image: %w", err)
+	}
+	defer f.Close()
+	cfg, err := config.LoadImage(f)
+	if err != nil {
+		return err
+	}
+	params, err := cfg.RenderParams()
+	if err != nil {
+		return fmt.Errorf("failed to read image view: %w", err)
+	}
+	g.animation = nil
+	g.planeAnimation = nil
+	g.planeTurned = false
+	if g.timeline != nil {
+		g.timeline.Stop()
+	}
+	g.mandelbrot.SetRenderParams(params)
+	g.toolbar.Refresh()
+	return nil
+}
+
+// saveImage writes what is on screen to a PNG carrying its view
+func (g *Game) saveImage(name string) error {
+	text, err := config.ImageText(g.mandelbrot.GetRenderParams(), g.software)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to create image: %w", err)
+	}
+	defer f.Close()
+	if err := png.Encode(pngtext.NewWriter(f, text...), g.mandelbrot.GetImage()); err != nil {
+		return fmt.Errorf("failed to encode image: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
 	return nil
 }
 
+// setView jumps to a view, keeping the scale and center setters in charge of
+// what can be reused
+func (g *Game) setView(view mandelbrot.View) {
+	g.mandelbrot.Scale(view.Scale)
+	g.mandelbrot.Center(view.Center)
+}
+
+// flyTo animates the explorer to a view
+func (g *Game) flyTo(view mandelbrot.View) {
+	from := mandelbrot.View{Center: g.mandelbrot.GetCenter(), Scale: g.mandelbrot.GetScale()}
+	g.animation = newViewAnimation(from, view)
+}
+
+// currentPlaneAngle returns the angle of the plane shown, 0 degrees for the
+// Mandelbrot plane and 90 for the Julia plane
+func (g *Game) currentPlaneAngle() float64 {
+	switch {
+	case g.mandelbrot.GetPlane() != nil:
+		return g.planeAngle
+	case g.mandelbrot.IsJulia():
+		return 90
+	default:
+		return 0
+	}
+}
+
+// setPlaneAngle shows the plane at an angle between the Mandelbrot plane
+// through the starting z and the Julia plane of the starting c. The ends
+// are left to the Mandelbrot and Julia renderers, which can do more.
+func (g *Game) setPlaneAngle(degrees float64) {
+	g.planeAngle = degrees
+	g.planeTurned = true
+	switch degrees {
+	case 0:
+		g.mandelbrot.SetPlane(nil)
+		g.mandelbrot.SetJulia(false)
+	case 90:
+		g.mandelbrot.SetPlane(nil)
+		g.mandelbrot.SetJulia(true)
+	default:
+		plane := mandelbrot.RotatedPlane(g.mandelbrot.GetStartingZ(), g.mandelbrot.GetStartingC(), degrees)
+		g.mandelbrot.SetPlane(&plane)
+	}
+}
+
+// currentPlane returns the plane shown, including the Mandelbrot and Julia
+// planes
+func (g *Game) currentPlane() mandelbrot.Plane {
+	if plane := g.mandelbrot.GetPlane(); plane != nil {
+		return *plane
+	}
+	return mandelbrot.RotatedPlane(g.mandelbrot.GetStartingZ(), g.mandelbrot.GetStartingC(), g.currentPlaneAngle())
+}
+
+// setPlane shows a plane set by hand, which stays put when the starting z
+// and c change
+func (g *Game) setPlane(plane mandelbrot.Plane) {
+	g.planeAnimation = nil
+	g.planeTurned = false
+	g.mandelbrot.SetPlane(&plane)
+}
+
+// rotatePlaneTo animates the plane shown to an angle
+func (g *Game) rotatePlaneTo(degrees float64) {
+	g.planeAnimation = newPlaneAnimation(g.currentPlaneAngle(), degrees)
+}
+
+// zoomToNucleus finds the minibrot of lowest period near a point on screen
+// and flies to it
+func (g *Game) zoomToNucleus(x, y int) {
+	params := g.mandelbrot.GetRenderParams()
+	// Search a box an eighth of the view across around the cursor
+	nucleus, err := params.FindNucleus(g.mandelbrot.ScreenToViewport(x, y), params.View.Scale/8)
+	if err != nil {
+		slog.Info("No minibrot found", "error", err)
+		return
+	}
+	slog.Info("Found minibrot", "nucleus", nucleus)
+	g.flyTo(nucleus.View())
+}
+
+// misiurewiczSearch is the preperiod and period of the Misiurewicz points
+// searched for
+type misiurewiczSearch struct {
+	preperiod int
+	period    int
+}
+
+// centerOnMisiurewicz finds the Misiurewicz point near a point on screen
+// and flies to it, keeping the zoom. The point is found in high precision
+// but the view center is a complex128, so flying there is only exact to
+// about 1e-16 of the point's magnitude. The full point is logged.
+func (g *Game) centerOnMisiurewicz(x, y int) {
+	params := g.mandelbrot.GetRenderParams()
+	point, err := params.FindMisiurewicz(g.mandelbrot.ScreenToViewport(x, y), g.misiurewicz.preperiod, g.misiurewicz.period)
+	if err != nil {
+		slog.Info("No Misiurewicz point found", "error", err)
+		return
+	}
+	slog.Info("Found Misiurewicz point", "point", point)
+	g.flyTo(mandelbrot.View{Center: point.Center, Scale: params.View.Scale})
+}
+
+// zoomToAngle traces the ray of an external angle and flies to where it lands
+func (g *Game) zoomToAngle(angle *big.Rat) {
+	params := g.mandelbrot.GetRenderParams()
+	points, err := params.TraceRay(angle, rayLandingDepth)
+	if err != nil {
+		slog.Info("Failed to trace ray", "angle", angle.RatString(), "error", err)
+		return
+	}
+	landing := mandelbrot.LandingView(points)
+	slog.Info("Ray lands", "angle", angle.RatString(), "point", landing.Center)
+	g.flyTo(landing)
+}
+
 func (g *Game) Draw(screen *ebiten.Image) {
+	if g.space.Enabled() {
+		g.space.Draw(screen)
+		g.ui.Draw(screen)
+		return
+	}
+	if g.timeline != nil && g.timeline.Enabled() {
+		g.timeline.Draw(screen)
+		g.ui.Draw(screen)
+		return
+	}
 	g.mandelbrot.Update()
 	screen.WritePixels(g.mandelbrot.GetFramebuffer())
+	g.rays.Draw(screen, g.mandelbrot)
+	g.orbit.Draw(screen, g.mandelbrot)
+	g.preview.Draw(screen)
 	g.ui.Draw(screen)
 }
 
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	g.mandelbrot.Relayout(outsideWidth, outsideHeight)
+	g.space.Resize(outsideWidth, outsideHeight)
+	if g.timeline != nil {
+		g.timeline.Resize(outsideWidth, outsideHeight)
+	}
 
 	return outsideWidth, outsideHeight
 }
@@ -0,0 +1,46 @@
+package game
+
+import (
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+// minibrotSearch scans the view for minibrots in the background, as the
+// scan runs Newton's method across the whole view and can take a while
+type minibrotSearch struct {
+	// generation tells the results of an earlier search from the latest one
+	generation int
+	results    chan minibrotResult
+	found      func([]mandelbrot.Nucleus)
+}
+
+type minibrotResult struct {
+	generation int
+	nuclei     []mandelbrot.Nucleus
+}
+
+func newMinibrotSearch() *minibrotSearch {
+	return &minibrotSearch{results: make(chan minibrotResult, 1)}
+}
+
+// Start searches the view of params, replacing any search still running.
+// found is called from Update once the search is done.
+func (s *minibrotSearch) Start(params mandelbrot.RenderParams, found func([]mandelbrot.Nucleus)) {
+	s.generation++
+	s.found = found
+	generation := s.generation
+	go func() {
+		s.results <- minibrotResult{generation: generation, nuclei: params.FindNuclei()}
+	}()
+}
+
+// Update hands over the results of a finished search
+func (s *minibrotSearch) Update() {
+	select {
+	case result := <-s.results:
+		if result.generation == s.generation && s.found != nil {
+			s.found(result.nuclei)
+			s.found = nil
+		}
+	default:
+	}
+}
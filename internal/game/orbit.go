@@ -0,0 +1,86 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// orbitMaxPoints is how much of an orbit gets drawn
+	orbitMaxPoints   = 4096
+	orbitLabelOffset = 12
+)
+
+//nolint:golint,gochecknoglobals
+var (
+	orbitLineColor  = color.RGBA{R: 255, G: 255, B: 255, A: 200}
+	orbitStartColor = color.RGBA{R: 255, G: 64, B: 64, A: 255}
+)
+
+// orbitOverlay draws the orbit of the point under the cursor while the
+// modifier key is held
+type orbitOverlay struct {
+	visible bool
+	orbit   mandelbrot.Orbit
+	x, y    int
+	// point and key are what the orbit was traced for, so it's only traced
+	// again when the cursor, view or fractal changes
+	point complex128
+	key   string
+}
+
+func (o *orbitOverlay) Update(m *mandelbrot.Mandelbrot, cursorX, cursorY int, held bool) {
+	o.visible = held
+	if !held {
+		return
+	}
+	o.x, o.y = cursorX, cursorY
+	params := m.GetRenderParams()
+	point := m.ScreenToViewport(cursorX, cursorY)
+	key := params.Key()
+	if o.orbit.Points != nil && point == o.point && key == o.key {
+		return
+	}
+	o.point, o.key = point, key
+	o.orbit = params.Orbit(point, orbitMaxPoints)
+}
+
+func (o *orbitOverlay) Draw(screen *ebiten.Image, m *mandelbrot.Mandelbrot) {
+	if !o.visible || len(o.orbit.Points) == 0 {
+		return
+	}
+	// The path is drawn in screen space, so points far off screen are
+	// clamped to keep the lines from overflowing
+	bounds := screen.Bounds()
+	limit := float32(max(bounds.Dx(), bounds.Dy()) * 4)
+	clamp := func(v int) float32 {
+		return max(-limit, min(limit, float32(v)))
+	}
+
+	prevX, prevY := m.ViewportToScreen(o.orbit.Points[0])
+	for _, point := range o.orbit.Points[1:] {
+		x, y := m.ViewportToScreen(point)
+		vector.StrokeLine(screen, clamp(prevX), clamp(prevY), clamp(x), clamp(y), 1, orbitLineColor, true)
+		prevX, prevY = x, y
+	}
+	startX, startY := m.ViewportToScreen(o.orbit.Points[0])
+	vector.DrawFilledCircle(screen, clamp(startX), clamp(startY), 3, orbitStartColor, true)
+
+	ebitenutil.DebugPrintAt(screen, o.label(), o.x+orbitLabelOffset, o.y+orbitLabelOffset)
+}
+
+func (o *orbitOverlay) label() string {
+	if o.orbit.Escaped {
+		return fmt.Sprintf("escaped at: %d", o.orbit.Iterations)
+	}
+	label := fmt.Sprintf("iterations: %d", o.orbit.Iterations)
+	if o.orbit.Period > 0 {
+		return fmt.Sprintf("%s\nperiod: %d", label, o.orbit.Period)
+	}
+	return fmt.Sprintf("%s\nperiod: unknown", label)
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	previewWidth  = 192
+	previewHeight = 128
+	previewMargin = 8
+	// previewMaxIterations keeps the preview quick enough to follow the cursor
+	previewMaxIterations = 200
+)
+
+//nolint:golint,gochecknoglobals
+var (
+	// previewView frames a whole Julia set
+	previewView   = mandelbrot.View{Center: complex(0.625, 0), Scale: 1.25}
+	previewBorder = color.White
+)
+
+// juliaPreview shows the Julia set of the c under the cursor in a small
+// picture-in-picture while exploring the Mandelbrot set
+type juliaPreview struct {
+	enabled  bool
+	visible  bool
+	image    *ebiten.Image
+	c        complex128
+	cursorX  int
+	cursorY  int
+	rendered bool
+}
+
+func newJuliaPreview() *juliaPreview {
+	return &juliaPreview{
+		image: ebiten.NewImage(previewWidth, previewHeight),
+	}
+}
+
+func (p *juliaPreview) SetEnabled(enabled bool) {
+	p.enabled = enabled
+	p.rendered = false
+}
+
+// Rect is where the preview is drawn on a screen of the given size
+func (p *juliaPreview) Rect(screenWidth, screenHeight int) image.Rectangle {
+	return image.Rect(
+		screenWidth-previewWidth-previewMargin,
+		screenHeight-previewHeight-previewMargin,
+		screenWidth-previewMargin,
+		screenHeight-previewMargin,
+	)
+}
+
+// Update re-renders the preview when the cursor moved to a new c
+func (p *juliaPreview) Update(m *mandelbrot.Mandelbrot, cursorX, cursorY int, hovered bool) {
+	// Only points of the Mandelbrot plane are a c to preview
+	p.visible = p.enabled && !m.IsJulia() && m.GetPlane() == nil
+	if !p.visible || hovered {
+		return
+	}
+	if p.rendered && cursorX == p.cursorX && cursorY == p.cursorY {
+		return
+	}
+	p.cursorX, p.cursorY = cursorX, cursorY
+	p.c = m.ScreenToViewport(cursorX, cursorY)
+	p.rendered = true
+
+	params := m.GetRenderParams()
+	params.Width = previewWidth
+	params.Height = previewHeight
+	params.View = previewView
+	params.Julia = true
+	params.StartingC = p.c
+	params.MaxIterations = min(params.MaxIterations, previewMaxIterations)
+	params.Lighting.Enabled = false
+	p.image.WritePixels(mandelbrot.Render(params).Pix)
+}
+
+// Promote makes the previewed Julia set the main view
+func (p *juliaPreview) Promote(m *mandelbrot.Mandelbrot) {
+	if !p.visible || !p.rendered {
+		return
+	}
+	m.SetStartingC(p.c)
+	m.SetJulia(true)
+	m.Scale(previewView.Scale)
+	m.Center(previewView.Center)
+}
+
+func (p *juliaPreview) Draw(screen *ebiten.Image) {
+	if !p.visible || !p.rendered {
+		return
+	}
+	rect := p.Rect(screen.Bounds().Dx(), screen.Bounds().Dy())
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(float64(rect.Min.X), float64(rect.Min.Y))
+	screen.DrawImage(p.image, op)
+	vector.StrokeRect(screen,
+		float32(rect.Min.X), float32(rect.Min.Y),
+		float32(rect.Dx()), float32(rect.Dy()),
+		1, previewBorder, false)
+}
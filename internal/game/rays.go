@@ -0,0 +1,95 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log/slog"
+	"math/big"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	// rayDrawDepth is how many levels of a ray get drawn
+	rayDrawDepth = 64
+	// rayLandingDepth is how far a ray is followed to find where it lands
+	rayLandingDepth = 512
+)
+
+//nolint:golint,gochecknoglobals
+var rayColor = color.RGBA{R: 255, G: 255, B: 0, A: 220}
+
+// rayOverlay draws external rays, or dynamic rays in Julia mode, on top of
+// the fractal
+type rayOverlay struct {
+	angles []*big.Rat
+	rays   [][]complex128
+	// key is the state the rays were traced for, they are traced again
+	// when the fractal changes under them
+	key string
+}
+
+func (o *rayOverlay) Add(angle *big.Rat) {
+	o.angles = append(o.angles, angle)
+	o.key = ""
+}
+
+func (o *rayOverlay) Clear() {
+	o.angles = nil
+	o.rays = nil
+	o.key = ""
+}
+
+func rayKey(p *mandelbrot.RenderParams) string {
+	key := fmt.Sprintf("%s|%v", p.Fractal.Name(), p.Params.Exponent)
+	if p.Julia {
+		return fmt.Sprintf("%s|julia|%v", key, p.StartingC)
+	}
+	return fmt.Sprintf("%s|%v", key, p.StartingZ)
+}
+
+func (o *rayOverlay) trace(p *mandelbrot.RenderParams) {
+	key := rayKey(p)
+	if key == o.key {
+		return
+	}
+	o.key = key
+	o.rays = make([][]complex128, len(o.angles))
+	for i, angle := range o.angles {
+		points, err := p.TraceRay(angle, rayDrawDepth)
+		if err != nil {
+			slog.Info("Failed to trace ray", "angle", angle.RatString(), "error", err)
+			continue
+		}
+		o.rays[i] = points
+	}
+}
+
+func (o *rayOverlay) Draw(screen *ebiten.Image, m *mandelbrot.Mandelbrot) {
+	if len(o.angles) == 0 {
+		return
+	}
+	params := m.GetRenderParams()
+	o.trace(&params)
+
+	bounds := screen.Bounds()
+	limit := float32(max(bounds.Dx(), bounds.Dy()) * 4)
+	clamp := func(v int) float32 {
+		return max(-limit, min(limit, float32(v)))
+	}
+	for i, points := range o.rays {
+		if len(points) == 0 {
+			continue
+		}
+		prevX, prevY := m.ViewportToScreen(points[0])
+		for _, point := range points[1:] {
+			x, y := m.ViewportToScreen(point)
+			vector.StrokeLine(screen, clamp(prevX), clamp(prevY), clamp(x), clamp(y), 1, rayColor, true)
+			prevX, prevY = x, y
+		}
+		ebitenutil.DebugPrintAt(screen, o.angles[i].RatString(), prevX+4, prevY+4)
+	}
+}
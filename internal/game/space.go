@@ -0,0 +1,143 @@
+package game
+
+import (
+	"github.com/USA-RedDragon/mandelbrot/internal/raymarch"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// spaceOrbitSpeed is degrees of camera orbit per pixel dragged
+	spaceOrbitSpeed = 0.4
+	// spaceMinDistance keeps the camera from flying into the fractal's center
+	spaceMinDistance = 0.1
+	// sliceRotateSpeed is degrees of slice rotation per tick a key is held
+	sliceRotateSpeed = 1.5
+)
+
+// spaceMode is the 3D fractal shown instead of the plane
+type spaceMode int
+
+const (
+	spaceModeOff spaceMode = iota
+	spaceModeMandelbulb
+	spaceModeQuaternionJulia
+)
+
+// spaceView shows a ray marched 3D fractal instead of the plane, refining it
+// in the background whenever the camera settles
+type spaceView struct {
+	mode       spaceMode
+	params     raymarch.Params
+	mandelbulb raymarch.Mandelbulb
+	julia      raymarch.QuaternionJulia
+	renderer   raymarch.Progressive
+	changed    bool
+}
+
+func newSpaceView(width, height int) *spaceView {
+	return &spaceView{
+		params:     raymarch.DefaultParams(width, height),
+		mandelbulb: *raymarch.NewMandelbulb(8),
+		julia:      *raymarch.NewQuaternionJulia(raymarch.Quaternion{}),
+		changed:    true,
+	}
+}
+
+func (s *spaceView) Enabled() bool {
+	return s.mode != spaceModeOff
+}
+
+func (s *spaceView) SetMode(mode spaceMode) {
+	if s.mode == mode {
+		return
+	}
+	s.mode = mode
+	s.changed = true
+	if mode == spaceModeOff {
+		s.renderer.Stop()
+	}
+}
+
+func (s *spaceView) SetPower(power float64) {
+	s.mandelbulb.Power = power
+	s.changed = true
+}
+
+func (s *spaceView) SetShadows(shadows bool) {
+	s.params.Shadows = shadows
+	s.changed = true
+}
+
+func (s *spaceView) SetAmbientOcclusion(occlusion bool) {
+	s.params.AmbientOcclusion = occlusion
+	s.changed = true
+}
+
+// SetQuaternionC sets the J and K parts of the quaternion constant. The
+// real and I parts follow the c of the plane.
+func (s *spaceView) SetQuaternionC(j, k float64) {
+	s.julia.C.J, s.julia.C.K = j, k
+	s.changed = true
+}
+
+func (s *spaceView) SetSlice(slice raymarch.Slice) {
+	s.julia.Slice = slice
+	s.changed = true
+}
+
+func (s *spaceView) RotateSlice(xw, yw, zw float64) {
+	s.julia.Slice.Rotate(xw, yw, zw)
+	s.changed = true
+}
+
+func (s *spaceView) Orbit(dx, dy int) {
+	s.params.Camera.Orbit(float64(dx)*spaceOrbitSpeed, float64(dy)*spaceOrbitSpeed)
+	s.changed = true
+}
+
+func (s *spaceView) Zoom(wheel float64) {
+	s.params.Camera.Distance = max(spaceMinDistance, s.params.Camera.Distance*(1-wheel*0.1))
+	s.changed = true
+}
+
+func (s *spaceView) Resize(width, height int) {
+	if s.params.Width == width && s.params.Height == height {
+		return
+	}
+	s.params.Width = width
+	s.params.Height = height
+	s.changed = true
+}
+
+// Update restarts the render after anything changed, including the c of
+// the plane that quaternion Julia sets are built on
+func (s *spaceView) Update(c complex128) {
+	if s.julia.C.R != real(c) || s.julia.C.I != imag(c) {
+		s.julia.C.R, s.julia.C.I = real(c), imag(c)
+		s.changed = s.changed || s.mode == spaceModeQuaternionJulia
+	}
+	if !s.Enabled() || !s.changed {
+		return
+	}
+	s.changed = false
+	// The renderer gets copies so it never sees a half made change
+	params := s.params
+	switch s.mode {
+	case spaceModeMandelbulb:
+		bulb := s.mandelbulb
+		params.Estimator = &bulb
+	case spaceModeQuaternionJulia:
+		julia := s.julia
+		params.Estimator = &julia
+	}
+	s.renderer.Start(params)
+}
+
+func (s *spaceView) Draw(screen *ebiten.Image) {
+	img, _ := s.renderer.Image()
+	// Until the first pass at a new size is done there is nothing to show
+	if img == nil || img.Rect.Size() != screen.Bounds().Size() {
+		return
+	}
+	screen.WritePixels(img.Pix)
+}
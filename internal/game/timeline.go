@@ -0,0 +1,152 @@
+package game
+
+import (
+	"image"
+	"sync"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/animation"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// timelineDownscale is how much smaller than the window timeline frames are
+// rendered, so playback keeps up with time
+const timelineDownscale = 2
+
+// timelinePreview plays a keyframe timeline in place of the plane. Frames
+// are rendered in the background at a reduced size, each one for wherever
+// playback is when the last one finished, so slow frames are skipped
+// rather than slowing time down.
+type timelinePreview struct {
+	timeline *animation.Timeline
+	base     mandelbrot.RenderParams
+	enabled  bool
+	paused   bool
+	// elapsed is how far into the timeline playback is, in seconds
+	elapsed float64
+	width   int
+	height  int
+	// stale is set when playback has moved or the size changed since the
+	// last frame was started, so a paused timeline isn't rendered again
+	stale bool
+
+	mu        sync.Mutex
+	frame     *image.RGBA
+	fresh     bool
+	rendering bool
+	// generation tells frames of an earlier playback from the current ones
+	generation int
+
+	image *ebiten.Image
+}
+
+func newTimelinePreview(timeline *animation.Timeline, width, height int) *timelinePreview {
+	return &timelinePreview{timeline: timeline, width: width, height: height}
+}
+
+func (p *timelinePreview) Enabled() bool {
+	return p.enabled
+}
+
+// Play starts the timeline from the beginning, taking whatever no keyframe
+// sets from base
+func (p *timelinePreview) Play(base mandelbrot.RenderParams) {
+	p.base = base
+	p.enabled = true
+	p.paused = false
+	p.elapsed = 0
+	p.stale = true
+	p.mu.Lock()
+	p.generation++
+	p.frame = nil
+	p.fresh = false
+	p.mu.Unlock()
+}
+
+func (p *timelinePreview) Stop() {
+	p.enabled = false
+	p.mu.Lock()
+	p.generation++
+	p.mu.Unlock()
+}
+
+func (p *timelinePreview) TogglePause() {
+	p.paused = !p.paused
+}
+
+func (p *timelinePreview) Resize(width, height int) {
+	if width != p.width || height != p.height {
+		p.width, p.height = width, height
+		p.stale = true
+	}
+}
+
+// Update advances playback, looping at the end, and starts rendering the
+// frame for now once the last one is done and playback has moved
+func (p *timelinePreview) Update() {
+	if !p.enabled {
+		return
+	}
+	if !p.paused {
+		p.elapsed += 1 / float64(ebiten.TPS())
+		if p.elapsed > p.timeline.Duration() {
+			p.elapsed = 0
+		}
+		p.stale = true
+	}
+	if !p.stale {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.rendering {
+		return
+	}
+	p.rendering = true
+	p.stale = false
+	// The frame renders while the explorer goes on, so it gets slices of
+	// its own
+	params := p.timeline.At(p.base, p.elapsed)
+	params.Params = params.Params.Clone()
+	params.Width = max(1, p.width/timelineDownscale)
+	params.Height = max(1, p.height/timelineDownscale)
+	generation := p.generation
+	go func() {
+		img := mandelbrot.Render(params)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.rendering = false
+		if generation == p.generation {
+			p.frame = img
+			p.fresh = true
+		}
+	}()
+}
+
+func (p *timelinePreview) Draw(screen *ebiten.Image) {
+	p.mu.Lock()
+	if p.fresh {
+		p.fresh = false
+		size := p.frame.Rect.Size()
+		if p.image == nil || p.image.Bounds().Size() != size {
+			if p.image != nil {
+				p.image.Deallocate()
+			}
+			p.image = ebiten.NewImage(size.X, size.Y)
+		}
+		p.image.WritePixels(p.frame.Pix)
+	}
+	p.mu.Unlock()
+	if p.image == nil {
+		return
+	}
+	bounds := screen.Bounds()
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(
+		float64(bounds.Dx())/float64(p.image.Bounds().Dx()),
+		float64(bounds.Dy())/float64(p.image.Bounds().Dy()),
+	)
+	op.Filter = ebiten.FilterLinear
+	screen.DrawImage(p.image, op)
+}
@@ -1,5 +1,17 @@
 package game
 
+import (
+	"fmt"
+	"log/slog"
+	"math/cmplx"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/config"
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
 // UIManager facilitates communication between the game and the UI
 type UIManager struct {
 	game *Game
@@ -16,9 +28,32 @@ func (m *UIManager) Exit() {
 }
 
 func (m *UIManager) Reset() {
+	m.game.planeAnimation = nil
 	m.game.mandelbrot.Reset()
 }
 
+// SaveView writes the current view to a config file in the working
+// directory, named after the time it was saved
+func (m *UIManager) SaveView() {
+	path := fmt.Sprintf("view-%s.yaml", time.Now().Format("20060102-150405"))
+	if err := config.SaveView(path, m.game.mandelbrot.GetRenderParams()); err != nil {
+		slog.Error("Failed to save view", "error", err)
+		return
+	}
+	slog.Info("Saved view", "path", path)
+}
+
+// SaveImage writes what is on screen to a PNG in the working directory,
+// carrying the view so it can be opened again
+func (m *UIManager) SaveImage() {
+	path := fmt.Sprintf("image-%s.png", time.Now().Format("20060102-150405"))
+	if err := m.game.saveImage(path); err != nil {
+		slog.Error("Failed to save image", "error", err)
+		return
+	}
+	slog.Info("Saved image", "path", path)
+}
+
 func (m *UIManager) SetExponentReal(exponent float64) {
 	m.game.mandelbrot.SetExponent(complex(exponent, 0))
 }
@@ -48,9 +83,417 @@ func (m *UIManager) IsJulia() bool {
 }
 
 func (m *UIManager) SetJulia(julia bool) {
+	// Picking a plane directly stops any turn between them
+	m.game.planeAnimation = nil
+	m.game.mandelbrot.SetPlane(nil)
 	m.game.mandelbrot.SetJulia(julia)
 }
 
 func (m *UIManager) SetMaxIterations(iterations uint64) {
 	m.game.mandelbrot.SetMaxIterations(iterations)
 }
+
+func (m *UIManager) IsAutoIterations() bool {
+	return m.game.mandelbrot.IsAutoIterations()
+}
+
+func (m *UIManager) SetAutoIterations(auto bool) {
+	m.game.mandelbrot.SetAutoIterations(auto)
+}
+
+func (m *UIManager) Fractals() []string {
+	return mandelbrot.FractalNames()
+}
+
+func (m *UIManager) GetFractal() string {
+	return m.game.mandelbrot.GetFractal().Name()
+}
+
+func (m *UIManager) SetFractal(name string) {
+	fractal, ok := mandelbrot.LookupFractal(name)
+	if !ok {
+		slog.Warn("unknown fractal", "fractal", name)
+		return
+	}
+	m.game.mandelbrot.SetFractal(fractal)
+}
+
+func (m *UIManager) FractalParameters() []string {
+	schema := m.game.mandelbrot.GetFractal().Parameters()
+	names := make([]string, len(schema))
+	for i, p := range schema {
+		names[i] = p.Name
+	}
+	return names
+}
+
+func (m *UIManager) IsHybrid() bool {
+	return m.game.mandelbrot.GetFractal().Name() == mandelbrot.FractalHybrid
+}
+
+func (m *UIManager) GetHybrid() string {
+	return mandelbrot.FormatHybrid(m.game.mandelbrot.GetHybrid())
+}
+
+// SetHybrid applies formulas typed like mandelbrot,burning-ship^3, ignoring
+// text that doesn't parse yet
+func (m *UIManager) SetHybrid(formulas string) {
+	steps, err := mandelbrot.ParseHybrid(formulas)
+	if err != nil {
+		return
+	}
+	m.game.mandelbrot.SetHybrid(steps)
+}
+
+func (m *UIManager) GetFractalParameter(name string) float64 {
+	value, err := m.game.mandelbrot.GetFractalParameter(name)
+	if err != nil {
+		slog.Warn("failed to get fractal parameter", "error", err)
+	}
+	return value
+}
+
+func (m *UIManager) SetFractalParameter(name string, value float64) {
+	if err := m.game.mandelbrot.SetFractalParameter(name, value); err != nil {
+		slog.Warn("failed to set fractal parameter", "error", err)
+	}
+}
+
+func (m *UIManager) IsLightingEnabled() bool {
+	return m.game.mandelbrot.GetLighting().Enabled
+}
+
+func (m *UIManager) SetLightingEnabled(enabled bool) {
+	lighting := m.game.mandelbrot.GetLighting()
+	lighting.Enabled = enabled
+	m.game.mandelbrot.SetLighting(lighting)
+}
+
+func (m *UIManager) SetLightAzimuth(degrees float64) {
+	lighting := m.game.mandelbrot.GetLighting()
+	lighting.Azimuth = degrees
+	m.game.mandelbrot.SetLighting(lighting)
+}
+
+func (m *UIManager) SetLightElevation(degrees float64) {
+	lighting := m.game.mandelbrot.GetLighting()
+	lighting.Elevation = degrees
+	m.game.mandelbrot.SetLighting(lighting)
+}
+
+func (m *UIManager) SetLightHeight(height float64) {
+	lighting := m.game.mandelbrot.GetLighting()
+	lighting.Height = height
+	m.game.mandelbrot.SetLighting(lighting)
+}
+
+func (m *UIManager) SetLightAmbient(ambient float64) {
+	lighting := m.game.mandelbrot.GetLighting()
+	lighting.Ambient = ambient
+	m.game.mandelbrot.SetLighting(lighting)
+}
+
+func (m *UIManager) IsEquipotentials() bool {
+	return m.game.mandelbrot.GetContours().Equipotentials
+}
+
+func (m *UIManager) SetEquipotentials(enabled bool) {
+	contours := m.game.mandelbrot.GetContours()
+	contours.Equipotentials = enabled
+	m.game.mandelbrot.SetContours(contours)
+}
+
+func (m *UIManager) IsFieldLines() bool {
+	return m.game.mandelbrot.GetContours().FieldLines
+}
+
+func (m *UIManager) SetFieldLines(enabled bool) {
+	contours := m.game.mandelbrot.GetContours()
+	contours.FieldLines = enabled
+	m.game.mandelbrot.SetContours(contours)
+}
+
+func (m *UIManager) SetEquipotentialDensity(density float64) {
+	contours := m.game.mandelbrot.GetContours()
+	contours.EquipotentialDensity = density
+	m.game.mandelbrot.SetContours(contours)
+}
+
+func (m *UIManager) SetFieldLineDensity(density float64) {
+	contours := m.game.mandelbrot.GetContours()
+	contours.FieldLineDensity = density
+	m.game.mandelbrot.SetContours(contours)
+}
+
+// SetContourColor takes a color like #rrggbb or #rrggbbaa
+func (m *UIManager) SetContourColor(hex string) {
+	color, err := parseHexColor(hex)
+	if err != nil {
+		slog.Warn("failed to set contour color", "error", err)
+		return
+	}
+	contours := m.game.mandelbrot.GetContours()
+	contours.Color = color
+	m.game.mandelbrot.SetContours(contours)
+}
+
+func parseHexColor(hex string) ([4]byte, error) {
+	color := [4]byte{0, 0, 0, 255}
+	digits := strings.TrimPrefix(hex, "#")
+	if len(digits) != 6 && len(digits) != 8 {
+		return color, fmt.Errorf("invalid color %q", hex)
+	}
+	for i := 0; i < len(digits); i += 2 {
+		v, err := strconv.ParseUint(digits[i:i+2], 16, 8)
+		if err != nil {
+			return color, fmt.Errorf("invalid color %q: %w", hex, err)
+		}
+		color[i/2] = byte(v)
+	}
+	return color, nil
+}
+
+func (m *UIManager) ColoringMethods() []string {
+	methods := mandelbrot.ColoringMethods()
+	names := make([]string, len(methods))
+	for i, method := range methods {
+		names[i] = string(method)
+	}
+	return names
+}
+
+func (m *UIManager) GetColoringMethod() string {
+	return string(m.game.mandelbrot.GetColoring().Method)
+}
+
+func (m *UIManager) SetColoringMethod(method string) {
+	coloring := m.game.mandelbrot.GetColoring()
+	coloring.Method = mandelbrot.ColoringMethod(method)
+	m.game.mandelbrot.SetColoring(coloring)
+}
+
+func (m *UIManager) SetStripeDensity(density float64) {
+	coloring := m.game.mandelbrot.GetColoring()
+	coloring.StripeDensity = density
+	m.game.mandelbrot.SetColoring(coloring)
+}
+
+func (m *UIManager) SetColoringSkip(skip int) {
+	coloring := m.game.mandelbrot.GetColoring()
+	coloring.Skip = skip
+	m.game.mandelbrot.SetColoring(coloring)
+}
+
+func (m *UIManager) IsMandelbulb() bool {
+	return m.game.space.mode == spaceModeMandelbulb
+}
+
+func (m *UIManager) SetMandelbulb(enabled bool) {
+	m.setSpaceMode(spaceModeMandelbulb, enabled)
+}
+
+func (m *UIManager) IsQuaternionJulia() bool {
+	return m.game.space.mode == spaceModeQuaternionJulia
+}
+
+func (m *UIManager) SetQuaternionJulia(enabled bool) {
+	m.setSpaceMode(spaceModeQuaternionJulia, enabled)
+}
+
+// setSpaceMode switches to or away from a 3D mode. Only one can be shown at
+// a time, so the toolbar is refreshed to uncheck the other.
+func (m *UIManager) setSpaceMode(mode spaceMode, enabled bool) {
+	switch {
+	case enabled:
+		m.game.space.SetMode(mode)
+	case m.game.space.mode == mode:
+		m.game.space.SetMode(spaceModeOff)
+	default:
+		return
+	}
+	m.game.dragging = false
+	m.game.toolbar.Refresh()
+}
+
+func (m *UIManager) SetMandelbulbPower(power float64) {
+	m.game.space.SetPower(power)
+}
+
+func (m *UIManager) IsShadows() bool {
+	return m.game.space.params.Shadows
+}
+
+func (m *UIManager) SetShadows(shadows bool) {
+	m.game.space.SetShadows(shadows)
+}
+
+func (m *UIManager) IsAmbientOcclusion() bool {
+	return m.game.space.params.AmbientOcclusion
+}
+
+func (m *UIManager) SetAmbientOcclusion(occlusion bool) {
+	m.game.space.SetAmbientOcclusion(occlusion)
+}
+
+func (m *UIManager) SetQuaternionJ(j float64) {
+	m.game.space.SetQuaternionC(j, m.game.space.julia.C.K)
+}
+
+func (m *UIManager) SetQuaternionK(k float64) {
+	m.game.space.SetQuaternionC(m.game.space.julia.C.J, k)
+}
+
+func (m *UIManager) SetSliceW(w float64) {
+	slice := m.game.space.julia.Slice
+	slice.W = w
+	m.game.space.SetSlice(slice)
+}
+
+func (m *UIManager) SetSliceXW(degrees float64) {
+	slice := m.game.space.julia.Slice
+	slice.XW = degrees
+	m.game.space.SetSlice(slice)
+}
+
+func (m *UIManager) SetSliceYW(degrees float64) {
+	slice := m.game.space.julia.Slice
+	slice.YW = degrees
+	m.game.space.SetSlice(slice)
+}
+
+func (m *UIManager) SetSliceZW(degrees float64) {
+	slice := m.game.space.julia.Slice
+	slice.ZW = degrees
+	m.game.space.SetSlice(slice)
+}
+
+func (m *UIManager) IsInverseJulia() bool {
+	return m.game.mandelbrot.GetJuliaMethod() == mandelbrot.JuliaMethodInverse
+}
+
+func (m *UIManager) SetInverseJulia(inverse bool) {
+	if inverse {
+		m.game.mandelbrot.SetJuliaMethod(mandelbrot.JuliaMethodInverse)
+	} else {
+		m.game.mandelbrot.SetJuliaMethod(mandelbrot.JuliaMethodEscapeTime)
+	}
+}
+
+func (m *UIManager) IsJuliaPreview() bool {
+	return m.game.preview.enabled
+}
+
+func (m *UIManager) SetJuliaPreview(preview bool) {
+	m.game.preview.SetEnabled(preview)
+}
+
+func (m *UIManager) GetPlaneAngle() float64 {
+	return m.game.currentPlaneAngle()
+}
+
+// SetPlaneAngle shows the plane through starting z and c at an angle, from
+// the Mandelbrot plane at 0 degrees to the Julia plane at 90
+func (m *UIManager) SetPlaneAngle(degrees float64) {
+	if degrees == m.game.currentPlaneAngle() {
+		return
+	}
+	m.game.planeAnimation = nil
+	m.game.setPlaneAngle(degrees)
+}
+
+// GetPlane returns the origin and directions of the plane shown, written
+// like z.re,z.im,c.re,c.im
+func (m *UIManager) GetPlane() (origin, u, v string) {
+	plane := m.game.currentPlane()
+	return mandelbrot.FormatVector(plane.Origin), mandelbrot.FormatVector(plane.U), mandelbrot.FormatVector(plane.V)
+}
+
+func (m *UIManager) SetPlaneOrigin(origin string) {
+	m.setPlaneVector(origin, func(plane *mandelbrot.Plane) *[4]float64 { return &plane.Origin })
+}
+
+func (m *UIManager) SetPlaneU(u string) {
+	m.setPlaneVector(u, func(plane *mandelbrot.Plane) *[4]float64 { return &plane.U })
+}
+
+func (m *UIManager) SetPlaneV(v string) {
+	m.setPlaneVector(v, func(plane *mandelbrot.Plane) *[4]float64 { return &plane.V })
+}
+
+// setPlaneVector replaces one vector of the plane shown
+func (m *UIManager) setPlaneVector(text string, vector func(*mandelbrot.Plane) *[4]float64) {
+	v, err := mandelbrot.ParseVector(text)
+	if err != nil {
+		slog.Error("Invalid plane vector", "error", err)
+		return
+	}
+	plane := m.game.currentPlane()
+	*vector(&plane) = v
+	m.game.setPlane(plane)
+}
+
+// RotatePlaneTo animates the plane shown to an angle
+func (m *UIManager) RotatePlaneTo(degrees float64) {
+	m.game.rotatePlaneTo(degrees)
+}
+
+// FindMinibrots searches the view for minibrots in the background and
+// calls found with a description of each one
+func (m *UIManager) FindMinibrots(found func(labels []string)) {
+	m.game.minibrotSearch.Start(m.game.mandelbrot.GetRenderParams(), func(nuclei []mandelbrot.Nucleus) {
+		m.game.minibrots = nuclei
+		labels := make([]string, len(nuclei))
+		for i, n := range nuclei {
+			labels[i] = fmt.Sprintf("Period %d, size %.3g", n.Period, cmplx.Abs(n.Size))
+		}
+		found(labels)
+	})
+}
+
+// ZoomToMinibrot flies to one of the minibrots of the last search
+func (m *UIManager) ZoomToMinibrot(index int) {
+	if index < 0 || index >= len(m.game.minibrots) {
+		return
+	}
+	m.game.flyTo(m.game.minibrots[index].View())
+}
+
+func (m *UIManager) GetMisiurewiczPreperiod() int {
+	return m.game.misiurewicz.preperiod
+}
+
+func (m *UIManager) SetMisiurewiczPreperiod(preperiod int) {
+	m.game.misiurewicz.preperiod = preperiod
+}
+
+func (m *UIManager) GetMisiurewiczPeriod() int {
+	return m.game.misiurewicz.period
+}
+
+func (m *UIManager) SetMisiurewiczPeriod(period int) {
+	m.game.misiurewicz.period = period
+}
+
+// AddRay draws the external ray of an angle in turns, such as 1/3
+func (m *UIManager) AddRay(angle string) {
+	a, err := mandelbrot.ParseAngle(angle)
+	if err != nil {
+		slog.Error("Invalid angle", "error", err)
+		return
+	}
+	m.game.rays.Add(a)
+}
+
+func (m *UIManager) ClearRays() {
+	m.game.rays.Clear()
+}
+
+// ZoomToAngle flies to where the external ray of an angle lands
+func (m *UIManager) ZoomToAngle(angle string) {
+	a, err := mandelbrot.ParseAngle(angle)
+	if err != nil {
+		slog.Error("Invalid angle", "error", err)
+		return
+	}
+	m.game.zoomToAngle(a)
+}
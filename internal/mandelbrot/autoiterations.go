@@ -0,0 +1,133 @@
+package mandelbrot
+
+import (
+	"math"
+)
+
+const (
+	// autoBaseIterations is the limit used for the unzoomed view
+	autoBaseIterations = 250
+	// autoIterationsPerOctave is added for every halving of the scale
+	autoIterationsPerOctave = 75
+	autoMinIterations       = 100
+	autoMaxIterations       = 1_000_000
+
+	// autoRaiseFraction is the share of unescaped pixels touching an escaped
+	// one above which the set may be under-iterated. A properly iterated
+	// interior is mostly solid, so only its rim touches the outside, but
+	// thin or small components are all rim however high the limit.
+	autoRaiseFraction = 0.3
+	// autoConvergedFraction is how much of the interior a raise has to
+	// reveal as escaping to be worth raising again. Below it the interior is
+	// really interior and the limit stops rising.
+	autoConvergedFraction = 0.01
+	// autoLowerFraction and autoLowerSlack decide when the limit is wasted:
+	// the rim is clean and no escaping pixel came close to the limit
+	autoLowerFraction = 0.05
+	autoLowerSlack    = 8
+	autoStep          = 1.5
+	autoMinFactor     = 1.0 / 16
+	autoMaxFactor     = 64
+)
+
+// SetAutoIterations picks the iteration limit from the zoom depth and the
+// rendered frame instead of a fixed value
+func (m *Mandelbrot) SetAutoIterations(auto bool) {
+	if m.autoIter == auto {
+		return
+	}
+	m.autoIter = auto
+	m.resetAutoFactor()
+	m.needsUpdate = true
+}
+
+// resetAutoFactor drops the correction learnt from earlier frames
+func (m *Mandelbrot) resetAutoFactor() {
+	m.autoFactor = 1
+	m.autoScale = m.scale
+	m.autoRaisedFrom = -1
+	m.autoConverged = false
+}
+
+// followAutoZoom forgets the correction once the view has been zoomed by
+// more than 2 since it was learnt, as what needed more iterations there
+// says little about here
+func (m *Mandelbrot) followAutoZoom() {
+	if math.Abs(math.Log2(m.scale/m.autoScale)) >= 1 {
+		m.resetAutoFactor()
+	}
+}
+
+func (m *Mandelbrot) IsAutoIterations() bool {
+	return m.autoIter
+}
+
+// autoIterations is the limit for the current scale, grown linearly with
+// the number of times the view has been zoomed by 2 and corrected by what
+// previous frames showed
+func (m *Mandelbrot) autoIterations() uint64 {
+	octaves := max(0, -math.Log2(m.scale))
+	limit := (autoBaseIterations + autoIterationsPerOctave*octaves) * m.autoFactor
+	return uint64(math.Round(min(autoMaxIterations, max(autoMinIterations, limit))))
+}
+
+// adjustAutoIterations inspects the frame just rendered and nudges the
+// correction factor, which triggers another render with the new limit. A
+// rim heavy interior only keeps raising the limit while each raise turns
+// enough of the interior into escaping points.
+func (m *Mandelbrot) adjustAutoIterations() {
+	interior, rim := 0, 0
+	highest := uint64(0)
+	for y := range m.height {
+		for x := range m.width {
+			n := m.samples[y*m.width+x].Iterations
+			if n != m.maxIterations {
+				highest = max(highest, n)
+				continue
+			}
+			interior++
+			if m.touchesEscaped(x, y) {
+				rim++
+			}
+		}
+	}
+
+	if m.autoRaisedFrom >= 0 {
+		revealed := m.autoRaisedFrom - interior
+		m.autoConverged = float64(revealed) < autoConvergedFraction*float64(m.autoRaisedFrom)
+		m.autoRaisedFrom = -1
+	}
+
+	factor := m.autoFactor
+	raised := false
+	switch {
+	case !m.autoConverged && interior > 0 && float64(rim)/float64(interior) > autoRaiseFraction:
+		factor = min(autoMaxFactor, factor*autoStep)
+		raised = true
+	case highest < m.maxIterations/autoLowerSlack &&
+		(interior == 0 || float64(rim)/float64(interior) < autoLowerFraction):
+		factor = max(autoMinFactor, factor/autoStep)
+	}
+	if factor == m.autoFactor {
+		return
+	}
+	m.autoFactor = factor
+	limit := m.autoIterations()
+	if raised && limit != m.maxIterations {
+		m.autoRaisedFrom = interior
+	}
+	m.setMaxIterations(limit)
+}
+
+func (m *Mandelbrot) touchesEscaped(x, y int) bool {
+	for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+		nx, ny := x+d[0], y+d[1]
+		if nx < 0 || nx >= m.width || ny < 0 || ny >= m.height {
+			continue
+		}
+		if m.samples[ny*m.width+nx].Iterations != m.maxIterations {
+			return true
+		}
+	}
+	return false
+}
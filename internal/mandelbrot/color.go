@@ -1,6 +1,7 @@
 package mandelbrot
 
 import (
+	"fmt"
 	"math"
 
 	"goki.dev/cam/hsl"
@@ -13,8 +14,45 @@ const (
 	PaletteModeSimpleRainbow
 )
 
+// Palette names used by config files and flags
+const (
+	PaletteGrayscale = "grayscale"
+	PaletteRainbow   = "rainbow"
+)
+
+// PaletteNames lists the palettes by name
+func PaletteNames() []string {
+	return []string{PaletteGrayscale, PaletteRainbow}
+}
+
+// LookupPaletteMode returns the mode of a palette name
+func LookupPaletteMode(name string) (PaletteMode, bool) {
+	switch name {
+	case PaletteGrayscale:
+		return PaletteModeSimpleGrayscale, true
+	case PaletteRainbow:
+		return PaletteModeSimpleRainbow, true
+	default:
+		return 0, false
+	}
+}
+
+func (m PaletteMode) String() string {
+	switch m {
+	case PaletteModeSimpleGrayscale:
+		return PaletteGrayscale
+	case PaletteModeSimpleRainbow:
+		return PaletteRainbow
+	default:
+		return fmt.Sprintf("palette(%d)", int(m))
+	}
+}
+
 type Palette struct {
 	mode PaletteMode
+	// offset turns the palette by a fraction of a cycle, leaving the
+	// interior alone
+	offset float64
 }
 
 func NewPalette(mode PaletteMode) *Palette {
@@ -23,8 +61,33 @@ func NewPalette(mode PaletteMode) *Palette {
 	}
 }
 
+func (p *Palette) Mode() PaletteMode {
+	return p.mode
+}
+
+func (p *Palette) Offset() float64 {
+	return p.offset
+}
+
+// WithOffset returns a copy of the palette turned by offset cycles
+func (p *Palette) WithOffset(offset float64) *Palette {
+	return &Palette{mode: p.mode, offset: offset}
+}
+
+// shift turns a position along the palette by the offset, wrapping around
+func (p *Palette) shift(t float64) float64 {
+	if p.offset == 0 {
+		return t
+	}
+	t += p.offset
+	return t - math.Floor(t)
+}
+
 func (p *Palette) colorGrayscale(n uint64, maxIterations uint64) [4]byte {
 	factor := math.Sqrt(float64(n) / float64(maxIterations))
+	if n < maxIterations {
+		factor = p.shift(factor)
+	}
 	intensity := math.Round(float64(maxIterations) * factor)
 	color := uint8(intensity * 255 / float64(maxIterations))
 	return [4]byte{color, color, color, 255}
@@ -33,6 +96,9 @@ func (p *Palette) colorGrayscale(n uint64, maxIterations uint64) [4]byte {
 func (p *Palette) colorRainbow(n uint64, maxIterations uint64) [4]byte {
 	factor := float32(n) / float32(maxIterations)
 	hue := factor * 360
+	if n < maxIterations {
+		hue = float32(p.shift(float64(factor))) * 360
+	}
 	r, g, b, a := hsl.New(hue, factor, 0.5).RGBA()
 	return [4]byte{uint8(r), uint8(g), uint8(b), uint8(a)}
 }
@@ -47,3 +113,60 @@ func (p *Palette) Color(n uint64, maxIterations uint64) [4]byte {
 		return [4]byte{0, 0, 0, 255}
 	}
 }
+
+// ColorAt maps a value between 0 and 1 onto the palette, for colorings that
+// aren't counting iterations
+func (p *Palette) ColorAt(t float64) [4]byte {
+	t = p.shift(min(1, max(0, t)))
+	switch p.mode {
+	case PaletteModeSimpleGrayscale:
+		color := uint8(math.Sqrt(t) * 255)
+		return [4]byte{color, color, color, 255}
+	case PaletteModeSimpleRainbow:
+		// RGBA returns 16 bit channels
+		r, g, b, _ := hsl.New(float32(t)*360, float32(t), 0.5).RGBA()
+		return [4]byte{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8), 255}
+	default:
+		return [4]byte{0, 0, 0, 255}
+	}
+}
+
+// Color16 is Color at 16 bits per channel. The 8 bit rainbow keeps only the
+// low byte of each channel, which wraps its colors around, while here the
+// channels are kept whole.
+func (p *Palette) Color16(n uint64, maxIterations uint64) [4]uint16 {
+	switch p.mode {
+	case PaletteModeSimpleGrayscale:
+		factor := math.Sqrt(float64(n) / float64(maxIterations))
+		if n < maxIterations {
+			factor = p.shift(factor)
+		}
+		color := uint16(math.Round(factor * math.MaxUint16))
+		return [4]uint16{color, color, color, math.MaxUint16}
+	case PaletteModeSimpleRainbow:
+		factor := float32(n) / float32(maxIterations)
+		hue := factor * 360
+		if n < maxIterations {
+			hue = float32(p.shift(float64(factor))) * 360
+		}
+		r, g, b, _ := hsl.New(hue, factor, 0.5).RGBA()
+		return [4]uint16{uint16(r), uint16(g), uint16(b), math.MaxUint16}
+	default:
+		return [4]uint16{0, 0, 0, math.MaxUint16}
+	}
+}
+
+// ColorAt16 is ColorAt at 16 bits per channel
+func (p *Palette) ColorAt16(t float64) [4]uint16 {
+	t = p.shift(min(1, max(0, t)))
+	switch p.mode {
+	case PaletteModeSimpleGrayscale:
+		color := uint16(math.Sqrt(t) * math.MaxUint16)
+		return [4]uint16{color, color, color, math.MaxUint16}
+	case PaletteModeSimpleRainbow:
+		r, g, b, _ := hsl.New(float32(t)*360, float32(t), 0.5).RGBA()
+		return [4]uint16{uint16(r), uint16(g), uint16(b), math.MaxUint16}
+	default:
+		return [4]uint16{0, 0, 0, math.MaxUint16}
+	}
+}
@@ -0,0 +1,126 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// averageBailout is the bailout radius used by colorings that average
+// along the orbit. The averages only settle once the orbit is far out, and
+// a larger radius doesn't change which points escape.
+const averageBailout = 1e4
+
+// ColoringMethod picks what the palette is indexed by in the exterior
+type ColoringMethod string
+
+const (
+	// ColoringIterations colors by escape iteration
+	ColoringIterations ColoringMethod = "iterations"
+	// ColoringStripe averages sin(density·arg z) along the orbit
+	ColoringStripe ColoringMethod = "stripe"
+	// ColoringTriangle averages where |z| falls between the bounds the
+	// triangle inequality puts on it
+	ColoringTriangle ColoringMethod = "triangle"
+	// ColoringCurvature averages how sharply the orbit turns
+	ColoringCurvature ColoringMethod = "curvature"
+)
+
+// ColoringMethods lists the methods in the order menus show them
+func ColoringMethods() []ColoringMethod {
+	return []ColoringMethod{ColoringIterations, ColoringStripe, ColoringTriangle, ColoringCurvature}
+}
+
+type Coloring struct {
+	Method ColoringMethod
+	// StripeDensity is how many stripes a turn of z makes
+	StripeDensity float64
+	// Skip is how many iterations at the start of the orbit are left out of
+	// the average, as they tend to be the same for neighbouring points
+	Skip int
+}
+
+func DefaultColoring() Coloring {
+	return Coloring{
+		Method:        ColoringIterations,
+		StripeDensity: 5,
+		Skip:          1,
+	}
+}
+
+// UsesAverage reports whether samples need an average along the orbit
+func (c *Coloring) UsesAverage() bool {
+	switch c.Method {
+	case ColoringStripe, ColoringTriangle, ColoringCurvature:
+		return true
+	default:
+		return false
+	}
+}
+
+// term is the value averaged for the n-th point of the orbit, given the
+// two before it. It is NaN where the method has no value.
+func (c *Coloring) term(z, prev, prevPrev, point complex128, degree float64) float64 {
+	switch c.Method {
+	case ColoringStripe:
+		return 0.5*math.Sin(c.StripeDensity*cmplx.Phase(z)) + 0.5
+	case ColoringTriangle:
+		// |prev^d| - |c| <= |z| <= |prev^d| + |c|
+		zd := math.Pow(cmplx.Abs(prev), degree)
+		low := math.Abs(zd - cmplx.Abs(point))
+		high := zd + cmplx.Abs(point)
+		if high == low {
+			return math.NaN()
+		}
+		return min(1, max(0, (cmplx.Abs(z)-low)/(high-low)))
+	case ColoringCurvature:
+		turn := (z - prev) / (prev - prevPrev)
+		if cmplxIsBad(turn) || turn == 0 {
+			return math.NaN()
+		}
+		return math.Abs(cmplx.Phase(turn)) / math.Pi
+	default:
+		return math.NaN()
+	}
+}
+
+// orbitAverage accumulates Coloring.term along an orbit
+type orbitAverage struct {
+	coloring     *Coloring
+	point        complex128
+	prev         complex128
+	prevPrev     complex128
+	sum, prevSum float64
+	count        int
+	n            int
+}
+
+func newOrbitAverage(coloring *Coloring, z, c complex128) orbitAverage {
+	return orbitAverage{coloring: coloring, point: c, prev: z, prevPrev: z}
+}
+
+// add takes the next point of the orbit and the degree of the formula that
+// reached it
+func (a *orbitAverage) add(z complex128, degree float64) {
+	a.n++
+	if a.n > a.coloring.Skip {
+		if t := a.coloring.term(z, a.prev, a.prevPrev, a.point, degree); !math.IsNaN(t) {
+			a.prevSum = a.sum
+			a.sum += t
+			a.count++
+		}
+	}
+	a.prevPrev, a.prev = a.prev, z
+}
+
+// averages returns the average over the whole orbit and the one without
+// its last point
+func (a *orbitAverage) averages() (average, previous float64) {
+	if a.count == 0 {
+		return 0, 0
+	}
+	average = a.sum / float64(a.count)
+	if a.count == 1 {
+		return average, average
+	}
+	return average, a.prevSum / float64(a.count-1)
+}
@@ -0,0 +1,79 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// contourWidth is the share of each band between two lines that is drawn
+// as the line
+const contourWidth = 0.08
+
+// Contours draws lines over the exterior on top of whatever the palette and
+// lighting produced. Equipotentials follow the smooth iteration count, field
+// lines follow the argument of the final z, which is where binary
+// decomposition would switch colors.
+type Contours struct {
+	Equipotentials bool
+	FieldLines     bool
+	// EquipotentialDensity is the number of equipotentials per iteration
+	EquipotentialDensity float64
+	// FieldLineDensity is the number of field lines per turn of the final
+	// z. Powers of two keep the lines continuous from one band to the next.
+	FieldLineDensity float64
+	// Color is blended over the base color by its alpha
+	Color [4]byte
+}
+
+func DefaultContours() Contours {
+	return Contours{
+		EquipotentialDensity: 1,
+		FieldLineDensity:     8,
+		Color:                [4]byte{255, 255, 255, 192},
+	}
+}
+
+func (c *Contours) Enabled() bool {
+	return c.Equipotentials || c.FieldLines
+}
+
+// draw blends the line color over a base color if the sample lies on a line
+func (c *Contours) draw(color [4]byte, smooth float64, z complex128) [4]byte {
+	if !c.onLine(smooth, z) {
+		return color
+	}
+	alpha := float64(c.Color[3]) / 255
+	for i := range 3 {
+		color[i] = byte(float64(color[i])*(1-alpha) + float64(c.Color[i])*alpha)
+	}
+	return color
+}
+
+// draw16 is draw for 16 bit colors
+func (c *Contours) draw16(color [4]uint16, smooth float64, z complex128) [4]uint16 {
+	if !c.onLine(smooth, z) {
+		return color
+	}
+	alpha := float64(c.Color[3]) / 255
+	for i := range 3 {
+		// 257 widens a byte to 16 bits, 0xff becoming 0xffff
+		color[i] = uint16(float64(color[i])*(1-alpha) + float64(c.Color[i])*257*alpha)
+	}
+	return color
+}
+
+// onLine reports whether a sample lies on an equipotential or field line
+func (c *Contours) onLine(smooth float64, z complex128) bool {
+	if c.Equipotentials && c.EquipotentialDensity > 0 {
+		_, frac := math.Modf(smooth * c.EquipotentialDensity)
+		if frac < contourWidth {
+			return true
+		}
+	}
+	if c.FieldLines && c.FieldLineDensity > 0 {
+		turns := cmplx.Phase(z)/(2*math.Pi) + 1
+		_, frac := math.Modf(turns * c.FieldLineDensity)
+		return frac < contourWidth
+	}
+	return false
+}
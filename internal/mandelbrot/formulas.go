@@ -0,0 +1,180 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	FractalMandelbrot  = "mandelbrot"
+	FractalBurningShip = "burning-ship"
+	FractalTricorn     = "tricorn"
+	FractalCeltic      = "celtic"
+	FractalHybrid      = "hybrid"
+)
+
+//nolint:golint,gochecknoinits
+func init() {
+	RegisterFractal(mandelbrotFractal{})
+	RegisterFractal(burningShipFractal{})
+	RegisterFractal(tricornFractal{})
+	RegisterFractal(celticFractal{})
+	RegisterFractal(hybridFractal{})
+}
+
+// bailoutParameter is shared by the built in formulas, which all escape
+// once |z| grows past it
+//
+//nolint:golint,gochecknoglobals
+var bailoutParameter = Parameter{
+	Name:        "bailout",
+	Description: "Escape radius",
+	Default:     2,
+}
+
+func bailout(params *Params) float64 {
+	if len(params.Values) == 0 {
+		return bailoutParameter.Default
+	}
+	return params.Values[0]
+}
+
+// pow raises z to the exponent, avoiding the general complex power for the
+// common squaring case
+func pow(z, exponent complex128) complex128 {
+	if exponent == 2 {
+		return z * z
+	}
+	return cmplx.Pow(z, exponent)
+}
+
+// maxInvertibleDegree bounds the number of preimages followed per step
+const maxInvertibleDegree = 8
+
+// integerDegree returns the exponent as a whole number of at least 2, which
+// is what the formulas need to have a finite set of preimages
+func integerDegree(params *Params) (int, bool) {
+	d := real(params.Exponent)
+	if imag(params.Exponent) != 0 || d != math.Trunc(d) || d < 2 || d > maxInvertibleDegree {
+		return 0, false
+	}
+	return int(d), true
+}
+
+// roots returns the d complex d-th roots of w
+func roots(w complex128, d int) []complex128 {
+	r := cmplx.Pow(w, complex(1/float64(d), 0))
+	out := make([]complex128, d)
+	for k := range d {
+		out[k] = r * cmplx.Rect(1, 2*math.Pi*float64(k)/float64(d))
+	}
+	return out
+}
+
+// mandelbrotFractal is z = z^p + c
+type mandelbrotFractal struct{}
+
+func (mandelbrotFractal) Name() string { return FractalMandelbrot }
+
+func (mandelbrotFractal) Iterate(z, c complex128, params *Params) complex128 {
+	return pow(z, params.Exponent) + c
+}
+
+func (mandelbrotFractal) Derivative(z, _ complex128, params *Params) complex128 {
+	if params.Exponent == 2 {
+		return 2 * z
+	}
+	return params.Exponent * cmplx.Pow(z, params.Exponent-1)
+}
+
+func (mandelbrotFractal) Bailout(params *Params) float64 { return bailout(params) }
+
+func (mandelbrotFractal) CanInvert(params *Params) bool {
+	_, ok := integerDegree(params)
+	return ok
+}
+
+func (mandelbrotFractal) Preimages(w, c complex128, params *Params) []complex128 {
+	d, _ := integerDegree(params)
+	return roots(w-c, d)
+}
+
+func (mandelbrotFractal) DefaultView() View {
+	return View{Center: complex(0, 0), Scale: 1}
+}
+
+func (mandelbrotFractal) Parameters() []Parameter {
+	return []Parameter{bailoutParameter}
+}
+
+// burningShipFractal is z = (|Re z| + i|Im z|)^p + c
+type burningShipFractal struct{}
+
+func (burningShipFractal) Name() string { return FractalBurningShip }
+
+func (burningShipFractal) Iterate(z, c complex128, params *Params) complex128 {
+	return pow(complex(math.Abs(real(z)), math.Abs(imag(z))), params.Exponent) + c
+}
+
+func (burningShipFractal) Bailout(params *Params) float64 { return bailout(params) }
+
+func (burningShipFractal) DefaultView() View {
+	return View{Center: complex(0, -0.55), Scale: 1}
+}
+
+func (burningShipFractal) Parameters() []Parameter {
+	return []Parameter{bailoutParameter}
+}
+
+// tricornFractal is z = conj(z)^p + c
+type tricornFractal struct{}
+
+func (tricornFractal) Name() string { return FractalTricorn }
+
+func (tricornFractal) Iterate(z, c complex128, params *Params) complex128 {
+	return pow(cmplx.Conj(z), params.Exponent) + c
+}
+
+func (tricornFractal) Bailout(params *Params) float64 { return bailout(params) }
+
+func (tricornFractal) CanInvert(params *Params) bool {
+	_, ok := integerDegree(params)
+	return ok
+}
+
+func (tricornFractal) Preimages(w, c complex128, params *Params) []complex128 {
+	d, _ := integerDegree(params)
+	preimages := roots(w-c, d)
+	for i, z := range preimages {
+		preimages[i] = cmplx.Conj(z)
+	}
+	return preimages
+}
+
+func (tricornFractal) DefaultView() View {
+	return View{Center: complex(-0.15, 0), Scale: 1.25}
+}
+
+func (tricornFractal) Parameters() []Parameter {
+	return []Parameter{bailoutParameter}
+}
+
+// celticFractal is z^p with its real part folded positive, plus c
+type celticFractal struct{}
+
+func (celticFractal) Name() string { return FractalCeltic }
+
+func (celticFractal) Iterate(z, c complex128, params *Params) complex128 {
+	w := pow(z, params.Exponent)
+	return complex(math.Abs(real(w)), imag(w)) + c
+}
+
+func (celticFractal) Bailout(params *Params) float64 { return bailout(params) }
+
+func (celticFractal) DefaultView() View {
+	return View{Center: complex(0, 0), Scale: 1.6}
+}
+
+func (celticFractal) Parameters() []Parameter {
+	return []Parameter{bailoutParameter}
+}
@@ -0,0 +1,167 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"sync"
+)
+
+// Fractal is an escape-time formula the explorer can render. Implementations
+// are stateless; everything that varies between renders is passed in Params.
+type Fractal interface {
+	// Name is the key the fractal is registered and configured under
+	Name() string
+	// Iterate advances z by one step of the orbit for c
+	Iterate(z, c complex128, params *Params) complex128
+	// Bailout is the radius beyond which an orbit is considered escaped
+	Bailout(params *Params) float64
+	// DefaultView is where the explorer starts for this fractal
+	DefaultView() View
+	// Parameters describes the formula specific values in Params.Values
+	Parameters() []Parameter
+}
+
+// Differentiable is implemented by fractals that know the derivative of
+// their formula with respect to z. Others get a finite difference estimate.
+type Differentiable interface {
+	Derivative(z, c complex128, params *Params) complex128
+}
+
+// derivativeStep is the finite difference step for fractals that aren't
+// Differentiable, relative to |z|
+const derivativeStep = 1e-7
+
+// derivative returns df/dz of the fractal's formula at z
+func derivative(f Fractal, z, c complex128, params *Params) complex128 {
+	if d, ok := f.(Differentiable); ok {
+		return d.Derivative(z, c, params)
+	}
+	h := complex(derivativeStep*max(1, cmplx.Abs(z)), 0)
+	return (f.Iterate(z+h, c, params) - f.Iterate(z, c, params)) / h
+}
+
+// Invertible is implemented by fractals whose formula can be run backwards,
+// which allows drawing Julia sets by inverse iteration
+type Invertible interface {
+	// CanInvert reports whether Preimages works with these params
+	CanInvert(params *Params) bool
+	// Preimages returns every z that Iterate maps to w
+	Preimages(w, c complex128, params *Params) []complex128
+}
+
+type Parameter struct {
+	Name        string
+	Description string
+	Default     float64
+}
+
+type Params struct {
+	Exponent complex128
+	// Values holds one entry per Parameter, in the order Parameters returns them
+	Values []float64
+	// Hybrid is the sequence of formulas the hybrid fractal applies in turn
+	Hybrid []HybridStep
+}
+
+// Clone returns a copy of the params that shares no memory with them, so
+// it can be handed to another goroutine
+func (p Params) Clone() Params {
+	p.Values = append([]float64(nil), p.Values...)
+	p.Hybrid = append([]HybridStep(nil), p.Hybrid...)
+	return p
+}
+
+type View struct {
+	Center complex128
+	Scale  float64
+}
+
+// InterpolateView returns the view a fraction t of the way from one view to
+// another. The scale changes exponentially so every doubling of zoom takes
+// the same time, and the center follows the scale so the target stays put
+// on screen while zooming.
+func InterpolateView(from, to View, t float64) View {
+	scale := from.Scale * math.Pow(to.Scale/from.Scale, t)
+	progress := t
+	if math.Abs(from.Scale-to.Scale) > 1e-12*from.Scale {
+		progress = (scale - from.Scale) / (to.Scale - from.Scale)
+	}
+	center := from.Center + (to.Center-from.Center)*complex(progress, 0)
+	return View{Center: center, Scale: scale}
+}
+
+// DefaultParams returns the Params a fractal starts out with
+func DefaultParams(f Fractal) Params {
+	schema := f.Parameters()
+	values := make([]float64, len(schema))
+	for i, p := range schema {
+		values[i] = p.Default
+	}
+	params := Params{
+		Exponent: complex(2, 0),
+		Values:   values,
+	}
+	if f.Name() == FractalHybrid {
+		params.Hybrid = DefaultHybrid()
+	}
+	return params
+}
+
+// ParameterIndex returns the position of the named parameter in Params.Values
+func ParameterIndex(f Fractal, name string) (int, error) {
+	for i, p := range f.Parameters() {
+		if p.Name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("fractal %s has no parameter %s", f.Name(), name)
+}
+
+//nolint:golint,gochecknoglobals
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Fractal{}
+	// registryOrder keeps fractals in registration order for menus and help
+	registryOrder []string
+)
+
+// RegisterFractal makes a fractal available by name. It panics if the name
+// is already taken, as that can only be a programming error.
+func RegisterFractal(f Fractal) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[f.Name()]; ok {
+		panic(fmt.Sprintf("fractal %s registered twice", f.Name()))
+	}
+	registry[f.Name()] = f
+	registryOrder = append(registryOrder, f.Name())
+}
+
+func LookupFractal(name string) (Fractal, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	f, ok := registry[name]
+	return f, ok
+}
+
+// Fractals returns every registered fractal in registration order
+func Fractals() []Fractal {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	fractals := make([]Fractal, len(registryOrder))
+	for i, name := range registryOrder {
+		fractals[i] = registry[name]
+	}
+	return fractals
+}
+
+func FractalNames() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return append([]string(nil), registryOrder...)
+}
@@ -0,0 +1,113 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// HybridStep is one formula in the sequence a hybrid fractal applies
+type HybridStep struct {
+	Fractal Fractal
+	// Exponent overrides the view's exponent for this step when it isn't 0
+	Exponent complex128
+}
+
+// exponent returns the exponent the step runs with
+func (s HybridStep) exponent(params *Params) complex128 {
+	if s.Exponent == 0 {
+		return params.Exponent
+	}
+	return s.Exponent
+}
+
+// String writes the step like "mandelbrot^3", leaving the exponent off when
+// it is the view's
+func (s HybridStep) String() string {
+	switch {
+	case s.Exponent == 0:
+		return s.Fractal.Name()
+	case imag(s.Exponent) == 0:
+		return s.Fractal.Name() + "^" + strconv.FormatFloat(real(s.Exponent), 'g', -1, 64)
+	default:
+		return s.Fractal.Name() + "^" + strconv.FormatComplex(s.Exponent, 'g', -1, 128)
+	}
+}
+
+// DefaultHybrid alternates the Mandelbrot set and the Burning Ship
+func DefaultHybrid() []HybridStep {
+	mandelbrot, _ := LookupFractal(FractalMandelbrot)
+	burningShip, _ := LookupFractal(FractalBurningShip)
+	return []HybridStep{{Fractal: mandelbrot}, {Fractal: burningShip}}
+}
+
+// ParseHybrid reads a comma separated sequence of formulas, each optionally
+// followed by its own exponent, like "mandelbrot,burning-ship,mandelbrot^3"
+func ParseHybrid(s string) ([]HybridStep, error) {
+	var steps []HybridStep
+	for _, field := range strings.Split(s, ",") {
+		name, exponent, hasExponent := strings.Cut(strings.TrimSpace(field), "^")
+		fractal, ok := LookupFractal(name)
+		if !ok || name == FractalHybrid {
+			return nil, fmt.Errorf("invalid hybrid formula %q", name)
+		}
+		step := HybridStep{Fractal: fractal}
+		if hasExponent {
+			e, err := strconv.ParseComplex(exponent, 128)
+			if err != nil || e == 0 {
+				return nil, fmt.Errorf("invalid exponent %q for %s", exponent, name)
+			}
+			step.Exponent = e
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// FormatHybrid writes a sequence the way ParseHybrid reads it
+func FormatHybrid(steps []HybridStep) string {
+	fields := make([]string, len(steps))
+	for i, step := range steps {
+		fields[i] = step.String()
+	}
+	return strings.Join(fields, ",")
+}
+
+// hybridFractal applies the formulas of Params.Hybrid in rotation, one per
+// iteration, so bailout is checked after each and the orbit's iteration n
+// runs formula n modulo the sequence length
+type hybridFractal struct{}
+
+func (hybridFractal) Name() string { return FractalHybrid }
+
+// Iterate applies the first formula of the sequence. Orbits take the rest
+// in turn through RenderParams.iterate, which knows the iteration.
+func (h hybridFractal) Iterate(z, c complex128, params *Params) complex128 {
+	if len(params.Hybrid) == 0 {
+		return z
+	}
+	f, stepParams := h.step(0, params)
+	return f.Iterate(z, c, stepParams)
+}
+
+// step returns the formula iteration n applies and the params it runs with
+func (hybridFractal) step(n uint64, params *Params) (Fractal, *Params) {
+	step := params.Hybrid[n%uint64(len(params.Hybrid))]
+	return step.Fractal, &Params{Exponent: step.exponent(params), Values: params.Values}
+}
+
+func (hybridFractal) Bailout(params *Params) float64 { return bailout(params) }
+
+func (hybridFractal) DefaultView() View {
+	return View{Center: complex(0, 0), Scale: 1.25}
+}
+
+func (hybridFractal) Parameters() []Parameter {
+	return []Parameter{bailoutParameter}
+}
+
+// degree is how fast the orbit grows at iteration n
+func (hybridFractal) degree(n uint64, params *Params) float64 {
+	return cmplx.Abs(params.Hybrid[n%uint64(len(params.Hybrid))].exponent(params))
+}
@@ -0,0 +1,66 @@
+package mandelbrot
+
+import "testing"
+
+func TestParseHybrid(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "mandelbrot", want: "mandelbrot", ok: true},
+		{in: "mandelbrot,burning-ship", want: "mandelbrot,burning-ship", ok: true},
+		{in: "mandelbrot,burning-ship,mandelbrot^3", want: "mandelbrot,burning-ship,mandelbrot^3", ok: true},
+		{in: "tricorn^2.5,celtic", want: "tricorn^2.5,celtic", ok: true},
+		{in: "burning-ship^-2", want: "burning-ship^-2", ok: true},
+		{in: "mandelbrot^(3+1i)", want: "mandelbrot^(3+1i)", ok: true},
+		{in: "tricorn^2+1i", want: "tricorn^(2+1i)", ok: true},
+		{in: " mandelbrot , burning-ship^3 ", want: "mandelbrot,burning-ship^3", ok: true},
+		{in: ""},
+		{in: "hybrid"},
+		{in: "mandelbrot,hybrid"},
+		{in: "julia"},
+		{in: "mandelbrot,"},
+		{in: "mandelbrot^0"},
+		{in: "mandelbrot^"},
+		{in: "mandelbrot^x"},
+	} {
+		steps, err := ParseHybrid(tt.in)
+		if (err == nil) != tt.ok {
+			t.Errorf("ParseHybrid(%q) returned %v, want ok %t", tt.in, err, tt.ok)
+			continue
+		}
+		if got := FormatHybrid(steps); tt.ok && got != tt.want {
+			t.Errorf("FormatHybrid(ParseHybrid(%q)) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHybridBailout(t *testing.T) {
+	hybrid, _ := LookupFractal(FractalHybrid)
+	// z is checked against the bailout after every formula, 0 skips it
+	for _, tt := range []struct {
+		hybrid     string
+		point      complex128
+		iterations uint64
+		z          complex128
+	}{
+		{hybrid: "mandelbrot,burning-ship", point: 3, iterations: 1, z: 3},
+		{hybrid: "mandelbrot^3,mandelbrot", point: 1.5, iterations: 2, z: 3.75},
+		{hybrid: "mandelbrot,mandelbrot^3", point: 1.5, iterations: 2, z: 4.875},
+		{hybrid: "mandelbrot,mandelbrot^3", point: 0.5, iterations: 6},
+	} {
+		p := DefaultRenderParams(1, 1)
+		p.Fractal = hybrid
+		p.Params = DefaultParams(hybrid)
+		p.Params.Hybrid, _ = ParseHybrid(tt.hybrid)
+		s := p.Sample(tt.point)
+		if s.Iterations != tt.iterations || (tt.z != 0 && s.Z != tt.z) {
+			t.Errorf("%s at %v escaped after %d iterations at %v, want %d at %v",
+				tt.hybrid, tt.point, s.Iterations, s.Z, tt.iterations, tt.z)
+		}
+		if orbit := p.Orbit(tt.point, 100); orbit.Iterations != tt.iterations {
+			t.Errorf("%s at %v has an orbit of %d iterations, want %d", tt.hybrid, tt.point, orbit.Iterations, tt.iterations)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package mandelbrot
+
+import (
+	"image"
+	"math"
+)
+
+// JuliaMethod selects how Julia sets are drawn
+type JuliaMethod string
+
+const (
+	// JuliaMethodEscapeTime iterates every pixel forwards and colors it by
+	// how quickly it escapes
+	JuliaMethodEscapeTime JuliaMethod = "escape-time"
+	// JuliaMethodInverse runs the formula backwards from a point on the
+	// Julia set, plotting the set itself. It copes with thin and
+	// disconnected sets that escape time leaves almost empty.
+	JuliaMethodInverse JuliaMethod = "inverse"
+)
+
+const (
+	// iimMaxHits is how often a pixel may be visited before the branch of
+	// preimages through it is pruned, which keeps dense areas from soaking
+	// up all the work while sparse arms are still being filled in
+	iimMaxHits = 2
+	// iimWarmup is the number of backward steps taken from an arbitrary
+	// start to land on the Julia set
+	iimWarmup = 64
+	// iimWorkPerPixel bounds the total number of points visited
+	iimWorkPerPixel = 64
+)
+
+//nolint:golint,gochecknoglobals
+var (
+	iimColor      = [4]byte{255, 255, 255, 255}
+	iimBackground = [4]byte{0, 0, 0, 255}
+)
+
+// UsesInverseIteration reports whether the params draw a Julia set by
+// inverse iteration
+func (p *RenderParams) UsesInverseIteration() bool {
+	if !p.Julia || p.Plane != nil || p.JuliaMethod != JuliaMethodInverse {
+		return false
+	}
+	inv, ok := p.Fractal.(Invertible)
+	return ok && inv.CanInvert(&p.Params)
+}
+
+// renderInverse draws the Julia set with the modified inverse iteration
+// method: a depth first walk of the tree of preimages that stops descending
+// through pixels that were already visited often enough. Pixels on the set
+// get a sample that never escaped, all others one that escaped immediately.
+func renderInverse(p RenderParams, dst *image.RGBA, samples []Sample) {
+	inv := p.Fractal.(Invertible)
+	bounds := dst.Bounds().Intersect(image.Rect(0, 0, p.Width, p.Height))
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := dst.PixOffset(x, y)
+			copy(dst.Pix[i:i+4], iimBackground[:])
+		}
+	}
+	if samples != nil {
+		for i := range samples {
+			samples[i] = Sample{}
+		}
+	}
+
+	vp := p.viewport()
+	pw, ph := p.PixelSize()
+	// Points outside of the view are tracked on the same pixel grid, but
+	// sparsely, so arms of the set that leave the view and come back in
+	// are still followed
+	hits := make(map[image.Point]int)
+	maxOutside := p.Width * p.Height * 4
+	outside := 0
+
+	z := complex(1, 0)
+	for range iimWarmup {
+		z = inv.Preimages(z, p.StartingC, &p.Params)[0]
+	}
+
+	type node struct {
+		z     complex128
+		depth uint64
+	}
+	stack := []node{{z: z}}
+	budget := p.Width * p.Height * iimWorkPerPixel
+	for len(stack) > 0 && budget > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		budget--
+
+		if cmplxIsBad(n.z) {
+			continue
+		}
+		pt := image.Pt(
+			int(math.Floor((real(n.z)-vp[0])/pw)),
+			int(math.Floor((imag(n.z)-vp[1])/ph)),
+		)
+		inside := pt.In(image.Rect(0, 0, p.Width, p.Height))
+		count, seen := hits[pt]
+		if !inside && !seen {
+			if outside >= maxOutside {
+				continue
+			}
+			outside++
+		}
+		if count >= iimMaxHits {
+			continue
+		}
+		hits[pt] = count + 1
+
+		if inside && pt.In(bounds) {
+			i := dst.PixOffset(pt.X, pt.Y)
+			copy(dst.Pix[i:i+4], iimColor[:])
+			if samples != nil {
+				samples[(pt.Y-dst.Rect.Min.Y)*dst.Rect.Dx()+pt.X-dst.Rect.Min.X] = Sample{Iterations: p.MaxIterations, Z: n.z}
+			}
+		}
+
+		if n.depth >= p.MaxIterations {
+			continue
+		}
+		for _, w := range inv.Preimages(n.z, p.StartingC, &p.Params) {
+			stack = append(stack, node{z: w, depth: n.depth + 1})
+		}
+	}
+}
+
+func cmplxIsBad(z complex128) bool {
+	return math.IsNaN(real(z)) || math.IsNaN(imag(z)) || math.IsInf(real(z), 0) || math.IsInf(imag(z), 0)
+}
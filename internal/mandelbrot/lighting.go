@@ -0,0 +1,100 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// lightingShininess is the Phong exponent of the specular highlight
+const lightingShininess = 20
+
+// Lighting shades the exterior as a height field whose slope follows the
+// derivative of the orbit, on top of whatever the palette produced.
+type Lighting struct {
+	Enabled bool
+	// Azimuth is the direction the light comes from in degrees, counter
+	// clockwise from the positive real axis
+	Azimuth float64
+	// Elevation is the angle of the light above the plane in degrees
+	Elevation float64
+	// Height scales the relief, 0 being flat
+	Height float64
+	// Ambient is the share of the palette color kept in full shadow
+	Ambient float64
+	// Specular is the strength of the highlight
+	Specular float64
+}
+
+func DefaultLighting() Lighting {
+	return Lighting{
+		Enabled:   false,
+		Azimuth:   45,
+		Elevation: 45,
+		Height:    1.5,
+		Ambient:   0.2,
+		Specular:  0.3,
+	}
+}
+
+// shade applies the light to a palette color given the final z and its
+// derivative
+func (l *Lighting) shade(color [4]byte, z, derivative complex128) [4]byte {
+	intensity, specular, ok := l.light(z, derivative)
+	if !ok {
+		return color
+	}
+	var shaded [4]byte
+	for i := range 3 {
+		v := float64(color[i])*intensity + specular*255
+		shaded[i] = uint8(min(255, max(0, math.Round(v))))
+	}
+	shaded[3] = color[3]
+	return shaded
+}
+
+// shade16 is shade for 16 bit colors
+func (l *Lighting) shade16(color [4]uint16, z, derivative complex128) [4]uint16 {
+	intensity, specular, ok := l.light(z, derivative)
+	if !ok {
+		return color
+	}
+	var shaded [4]uint16
+	for i := range 3 {
+		v := float64(color[i])*intensity + specular*math.MaxUint16
+		shaded[i] = uint16(min(math.MaxUint16, max(0, math.Round(v))))
+	}
+	shaded[3] = color[3]
+	return shaded
+}
+
+// light returns how much of the palette color is lit and the strength of
+// the highlight. The normal of the height field points along z/dz, and ok
+// is false where that has no direction.
+func (l *Lighting) light(z, derivative complex128) (intensity, specular float64, ok bool) {
+	u := z / derivative
+	if cmplx.IsNaN(u) || cmplx.IsInf(u) || u == 0 {
+		return 0, 0, false
+	}
+	u /= complex(cmplx.Abs(u), 0)
+
+	nx, ny, nz := normalize(real(u)*l.Height, imag(u)*l.Height, 1)
+
+	azimuth := l.Azimuth * math.Pi / 180
+	elevation := l.Elevation * math.Pi / 180
+	lx := math.Cos(azimuth) * math.Cos(elevation)
+	ly := math.Sin(azimuth) * math.Cos(elevation)
+	lz := math.Sin(elevation)
+
+	diffuse := max(0, nx*lx+ny*ly+nz*lz)
+	// The viewer looks straight down, so the half vector is between the
+	// light and +z
+	hx, hy, hz := normalize(lx, ly, lz+1)
+	specular = l.Specular * math.Pow(max(0, nx*hx+ny*hy+nz*hz), lightingShininess)
+
+	return l.Ambient + (1-l.Ambient)*diffuse, specular, true
+}
+
+func normalize(x, y, z float64) (float64, float64, float64) {
+	length := math.Sqrt(x*x + y*y + z*z)
+	return x / length, y / length, z / length
+}
@@ -1,28 +1,44 @@
 package mandelbrot
 
 import (
+	"image"
+	"math"
 	"math/cmplx"
 	"sync"
 )
 
 type Mandelbrot struct {
 	width, height int
-	framebuffer   []byte
+	framebuffer   *image.RGBA
+	samples       []Sample
+	dirty         []image.Rectangle
 	maxIterations uint64
+	autoIter      bool
+	autoFactor    float64
+	// autoScale is the scale autoFactor was learnt at
+	autoScale float64
+	// autoRaisedFrom is the interior pixel count before the limit was last
+	// raised, or -1 when the last frame didn't raise it
+	autoRaisedFrom int
+	// autoConverged is set once raising the limit stopped changing the
+	// interior
+	autoConverged bool
 	needsUpdate   bool
 	scale         float64
 	center        complex128
-	exponent      complex128
+	rotation      float64
+	fractal       Fractal
+	params        Params
 	startingZ     complex128
 	startingC     complex128
 	julia         bool
+	plane         *Plane
 	palette       *Palette
-}
-
-type MandelbrotPixel struct {
-	X     int
-	Y     int
-	Color [4]byte
+	lighting      Lighting
+	contours      Contours
+	coloring      Coloring
+	juliaMethod   JuliaMethod
+	tileCache     *TileCache
 }
 
 const (
@@ -32,29 +48,119 @@ const (
 	boundMaxY = 1
 )
 
+// panTolerance is how far from a whole pixel a pan offset may be
+// and still be treated as pixel-aligned
+const panTolerance = 1e-6
+
 func NewMandelbrot(width, height int) *Mandelbrot {
+	p := DefaultRenderParams(width, height)
 	return &Mandelbrot{
-		width:         width,
-		height:        height,
-		framebuffer:   make([]byte, width*height*4),
-		maxIterations: 1000,
-		needsUpdate:   true,
-		scale:         1,
-		center:        complex(0, 0),
-		exponent:      complex(2, 0),
-		startingZ:     complex(0, 0),
-		startingC:     complex(-0.63, 0.34),
-		julia:         false,
-		palette:       NewPalette(PaletteModeSimpleRainbow),
+		width:          width,
+		height:         height,
+		framebuffer:    image.NewRGBA(image.Rect(0, 0, width, height)),
+		samples:        make([]Sample, width*height),
+		maxIterations:  p.MaxIterations,
+		autoFactor:     1,
+		autoScale:      p.View.Scale,
+		autoRaisedFrom: -1,
+		needsUpdate:    true,
+		scale:          p.View.Scale,
+		center:         p.View.Center,
+		rotation:       p.Rotation,
+		fractal:        p.Fractal,
+		params:         p.Params,
+		startingZ:      p.StartingZ,
+		startingC:      p.StartingC,
+		julia:          p.Julia,
+		plane:          p.Plane,
+		palette:        p.Palette,
+		lighting:       p.Lighting,
+		contours:       p.Contours,
+		coloring:       p.Coloring,
+		juliaMethod:    p.JuliaMethod,
+	}
+}
+
+// SetTileCache makes Update look up and store tiles in cache instead of
+// computing every pixel directly. A nil cache turns this off.
+func (m *Mandelbrot) SetTileCache(cache *TileCache) {
+	if m.tileCache == cache {
+		return
 	}
+	m.tileCache = cache
+	m.needsUpdate = true
+}
+
+func (m *Mandelbrot) Size() (width, height int) {
+	return m.width, m.height
 }
 
 func (m *Mandelbrot) GetFramebuffer() []byte {
+	return m.framebuffer.Pix
+}
+
+// GetImage returns the rendered view. It is reused between updates.
+func (m *Mandelbrot) GetImage() *image.RGBA {
 	return m.framebuffer
 }
 
 func (m *Mandelbrot) GetExponent() complex128 {
-	return m.exponent
+	return m.params.Exponent
+}
+
+func (m *Mandelbrot) GetFractal() Fractal {
+	return m.fractal
+}
+
+// SetFractal switches formula, resetting its parameters and the view to the
+// fractal's defaults. The exponent carries over as all formulas share it.
+func (m *Mandelbrot) SetFractal(fractal Fractal) {
+	if m.fractal.Name() == fractal.Name() {
+		return
+	}
+	exponent := m.params.Exponent
+	m.fractal = fractal
+	m.params = DefaultParams(fractal)
+	m.params.Exponent = exponent
+	view := fractal.DefaultView()
+	m.center = view.Center
+	m.scale = view.Scale
+	m.needsUpdate = true
+}
+
+func (m *Mandelbrot) GetFractalParameter(name string) (float64, error) {
+	i, err := ParameterIndex(m.fractal, name)
+	if err != nil {
+		return 0, err
+	}
+	return m.params.Values[i], nil
+}
+
+func (m *Mandelbrot) SetFractalParameter(name string, value float64) error {
+	i, err := ParameterIndex(m.fractal, name)
+	if err != nil {
+		return err
+	}
+	if m.params.Values[i] == value {
+		return nil
+	}
+	m.params.Values[i] = value
+	m.needsUpdate = true
+	return nil
+}
+
+// GetHybrid returns the formulas the hybrid fractal applies in turn
+func (m *Mandelbrot) GetHybrid() []HybridStep {
+	return m.params.Hybrid
+}
+
+// SetHybrid changes the formulas the hybrid fractal applies in turn
+func (m *Mandelbrot) SetHybrid(steps []HybridStep) {
+	if FormatHybrid(m.params.Hybrid) == FormatHybrid(steps) {
+		return
+	}
+	m.params.Hybrid = append([]HybridStep(nil), steps...)
+	m.needsUpdate = true
 }
 
 func (m *Mandelbrot) GetStartingZ() complex128 {
@@ -65,7 +171,17 @@ func (m *Mandelbrot) GetStartingC() complex128 {
 	return m.startingC
 }
 
+// SetMaxIterations sets a fixed iteration limit, overriding auto iterations
 func (m *Mandelbrot) SetMaxIterations(max uint64) {
+	m.autoIter = false
+	m.setMaxIterations(max)
+}
+
+func (m *Mandelbrot) GetMaxIterations() uint64 {
+	return m.maxIterations
+}
+
+func (m *Mandelbrot) setMaxIterations(max uint64) {
 	if m.maxIterations == max {
 		return
 	}
@@ -73,14 +189,49 @@ func (m *Mandelbrot) SetMaxIterations(max uint64) {
 	m.needsUpdate = true
 }
 
+// SetRenderParams replaces the explorer state with p, keeping the current size
+func (m *Mandelbrot) SetRenderParams(p RenderParams) {
+	m.fractal = p.Fractal
+	m.params = p.Params.Clone()
+	m.center = p.View.Center
+	m.scale = p.View.Scale
+	m.rotation = p.Rotation
+	m.palette = p.Palette
+	m.maxIterations = p.MaxIterations
+	m.julia = p.Julia
+	m.plane = p.Plane
+	m.startingZ = p.StartingZ
+	m.startingC = p.StartingC
+	m.lighting = p.Lighting
+	m.contours = p.Contours
+	m.coloring = p.Coloring
+	m.juliaMethod = p.JuliaMethod
+	m.needsUpdate = true
+}
+
+// GetRenderParams returns the params describing the current view. They
+// share no memory with the explorer, so they can be used from another
+// goroutine while the view changes.
+func (m *Mandelbrot) GetRenderParams() RenderParams {
+	p := m.renderParams()
+	p.Params = p.Params.Clone()
+	return p
+}
+
+// Reset returns to the defaults while staying on the current fractal
 func (m *Mandelbrot) Reset() {
-	m.scale = 1
-	m.center = complex(0, 0)
-	m.exponent = complex(2, 0)
-	m.startingZ = complex(0, 0)
-	m.startingC = complex(-0.63, 0.34)
-	m.maxIterations = 1000
-	m.julia = false
+	p := DefaultRenderParams(m.width, m.height)
+	view := m.fractal.DefaultView()
+	m.scale = view.Scale
+	m.center = view.Center
+	m.rotation = p.Rotation
+	m.params = DefaultParams(m.fractal)
+	m.startingZ = p.StartingZ
+	m.startingC = p.StartingC
+	m.maxIterations = p.MaxIterations
+	m.resetAutoFactor()
+	m.julia = p.Julia
+	m.plane = nil
 	m.needsUpdate = true
 }
 
@@ -101,17 +252,18 @@ func (m *Mandelbrot) SetStartingZ(startingZ complex128) {
 }
 
 func (m *Mandelbrot) SetExponent(exponent complex128) {
-	if m.exponent == exponent {
+	if m.params.Exponent == exponent {
 		return
 	}
-	m.exponent = exponent
+	m.params.Exponent = exponent
 	m.needsUpdate = true
 }
 
 func (m *Mandelbrot) ScaleBy(factor float64) {
 	newscale := m.scale * factor
-	if newscale > 1 {
-		newscale = 1
+	// Zooming out further than the fractal's default view only shows emptiness
+	if limit := m.fractal.DefaultView().Scale; newscale > limit {
+		newscale = limit
 	}
 	if newscale == m.scale {
 		return
@@ -128,6 +280,10 @@ func (m *Mandelbrot) Scale(scale float64) {
 	m.needsUpdate = true
 }
 
+func (m *Mandelbrot) GetScale() float64 {
+	return m.scale
+}
+
 func (m *Mandelbrot) GetCenter() complex128 {
 	return m.center
 }
@@ -144,101 +300,281 @@ func (m *Mandelbrot) IsJulia() bool {
 	return m.julia
 }
 
-func (m *Mandelbrot) viewport() [4]float64 {
-	return [4]float64{
-		boundMinX*m.scale + real(m.center),
-		boundMinY*m.scale + imag(m.center),
-		boundMaxX*m.scale + real(m.center),
-		boundMaxY*m.scale + imag(m.center),
+// GetPlane returns the slice through starting z and c being shown, or nil
+// for the Mandelbrot or Julia plane
+func (m *Mandelbrot) GetPlane() *Plane {
+	return m.plane
+}
+
+// SetPlane shows a slice through starting z and c instead of the Mandelbrot
+// or Julia plane, or goes back to them when plane is nil
+func (m *Mandelbrot) SetPlane(plane *Plane) {
+	if m.plane == nil && plane == nil || m.plane != nil && plane != nil && *m.plane == *plane {
+		return
 	}
+	m.plane = plane
+	m.needsUpdate = true
 }
 
-func (m *Mandelbrot) ViewportToScreen(point complex128) (x, y int) {
-	vp := m.viewport()
+func (m *Mandelbrot) GetJuliaMethod() JuliaMethod {
+	return m.juliaMethod
+}
 
-	x = int((real(point)-vp[0])/(vp[2]-vp[0])*float64(m.width)) + 1
-	y = int((imag(point)-vp[1])/(vp[3]-vp[1])*float64(m.height)) + 1
+func (m *Mandelbrot) SetJuliaMethod(method JuliaMethod) {
+	if m.juliaMethod == method {
+		return
+	}
+	m.juliaMethod = method
+	m.needsUpdate = true
+}
 
-	return
+func (m *Mandelbrot) GetLighting() Lighting {
+	return m.lighting
 }
 
-func (m *Mandelbrot) ScreenToViewport(x, y int) complex128 {
-	vp := m.viewport()
+func (m *Mandelbrot) SetLighting(lighting Lighting) {
+	if m.lighting == lighting {
+		return
+	}
+	m.lighting = lighting
+	m.needsUpdate = true
+}
+
+func (m *Mandelbrot) GetContours() Contours {
+	return m.contours
+}
+
+func (m *Mandelbrot) SetContours(contours Contours) {
+	if m.contours == contours {
+		return
+	}
+	m.contours = contours
+	m.needsUpdate = true
+}
+
+func (m *Mandelbrot) GetColoring() Coloring {
+	return m.coloring
+}
 
-	real := float64(x)/float64(m.width)*vp[2] + (1-float64(x)/float64(m.width))*vp[0]
-	imag := float64(y)/float64(m.height)*vp[3] + (1-float64(y)/float64(m.height))*vp[1]
+func (m *Mandelbrot) SetColoring(coloring Coloring) {
+	if m.coloring == coloring {
+		return
+	}
+	m.coloring = coloring
+	m.needsUpdate = true
+}
 
-	return complex(real, imag)
+// renderParams snapshots the explorer state for the renderer
+func (m *Mandelbrot) renderParams() RenderParams {
+	return RenderParams{
+		Fractal:       m.fractal,
+		Params:        m.params,
+		View:          View{Center: m.center, Scale: m.scale},
+		Rotation:      m.rotation,
+		Width:         m.width,
+		Height:        m.height,
+		Palette:       m.palette,
+		MaxIterations: m.maxIterations,
+		Julia:         m.julia,
+		Plane:         m.plane,
+		StartingZ:     m.startingZ,
+		StartingC:     m.startingC,
+		Lighting:      m.lighting,
+		Contours:      m.contours,
+		Coloring:      m.coloring,
+		JuliaMethod:   m.juliaMethod,
+	}
+}
+
+func (m *Mandelbrot) ViewportToScreen(point complex128) (x, y int) {
+	p := m.renderParams()
+	return p.Pixel(point)
+}
+
+func (m *Mandelbrot) ScreenToViewport(x, y int) complex128 {
+	p := m.renderParams()
+	return p.Point(x, y)
 }
 
 func (m *Mandelbrot) Center(center complex128) {
+	if m.center == center {
+		return
+	}
+	dx, dy, aligned := m.pixelOffset(center - m.center)
 	m.center = center
-	m.needsUpdate = true
+	if m.needsUpdate || !aligned {
+		m.needsUpdate = true
+		return
+	}
+	m.shift(dx, dy)
 }
 
-func (m *Mandelbrot) Update() {
-	if !m.needsUpdate {
-		return
-	}
-	m.needsUpdate = false
-
-	pixelChan := make(chan MandelbrotPixel, m.width*m.height)
-	fbWG := sync.WaitGroup{}
-	fbWG.Add(1)
-	go func() {
-		defer fbWG.Done()
-		count := 0
-		for pixel := range pixelChan {
-			i := (pixel.Y*m.width + pixel.X) * 4
-			copy(m.framebuffer[i:i+4], pixel.Color[:])
-			count++
-			if count == m.width*m.height {
-				close(pixelChan)
-				return
-			}
-		}
-	}()
+// pixelOffset converts a change of center into a whole number of screen pixels.
+// aligned is false when the change doesn't land on the pixel grid or moves
+// the view by more than a screen, in which case nothing can be reused.
+func (m *Mandelbrot) pixelOffset(delta complex128) (dx, dy int, aligned bool) {
+	p := m.renderParams()
+	pw, ph := p.PixelSize()
+	// The pixel grid turns with the view
+	if m.rotation != 0 {
+		delta *= cmplx.Rect(1, -m.rotation*math.Pi/180)
+	}
+	fx := real(delta) / pw
+	fy := imag(delta) / ph
 
-	pixelWG := sync.WaitGroup{}
+	rx, ry := math.Round(fx), math.Round(fy)
+	if math.Abs(fx-rx) > panTolerance || math.Abs(fy-ry) > panTolerance {
+		return 0, 0, false
+	}
+
+	dx, dy = int(rx), int(ry)
+	if dx <= -m.width || dx >= m.width || dy <= -m.height || dy >= m.height {
+		return 0, 0, false
+	}
+	return dx, dy, true
+}
+
+// shift moves the existing pixels by (-dx, -dy) and marks the newly
+// exposed strips as dirty so only they are computed on the next Update.
+func (m *Mandelbrot) shift(dx, dy int) {
+	if dx == 0 && dy == 0 {
+		return
+	}
+
+	framebuffer := image.NewRGBA(m.framebuffer.Rect)
+	samples := make([]Sample, len(m.samples))
+	x0, x1 := max(0, -dx), min(m.width, m.width-dx)
 	for y := range m.height {
-		for x := range m.width {
-			pixelWG.Add(1)
-			go func(x, y int) {
-				defer pixelWG.Done()
-				var z complex128
-				var c complex128
-				if m.julia {
-					z = m.ScreenToViewport(x, y)
-					c = m.startingC
-				} else {
-					z = m.startingZ
-					c = m.ScreenToViewport(x, y)
-				}
-
-				pixelChan <- m.mandelbrot(x, y, z, m.exponent, c)
-			}(x, y)
+		sy := y + dy
+		if sy < 0 || sy >= m.height {
+			continue
+		}
+		dst := y*m.width + x0
+		src := sy*m.width + x0 + dx
+		n := x1 - x0
+		copy(framebuffer.Pix[dst*4:(dst+n)*4], m.framebuffer.Pix[src*4:(src+n)*4])
+		copy(samples[dst:dst+n], m.samples[src:src+n])
+	}
+	m.framebuffer = framebuffer
+	m.samples = samples
+
+	bounds := image.Rect(0, 0, m.width, m.height)
+	dirty := make([]image.Rectangle, 0, len(m.dirty)+2)
+	// Anything still waiting to be computed moves along with the pixels
+	for _, r := range m.dirty {
+		r = r.Sub(image.Pt(dx, dy)).Intersect(bounds)
+		if !r.Empty() {
+			dirty = append(dirty, r)
 		}
 	}
-	pixelWG.Wait()
-	fbWG.Wait()
+	switch {
+	case dx > 0:
+		dirty = append(dirty, image.Rect(m.width-dx, 0, m.width, m.height))
+	case dx < 0:
+		dirty = append(dirty, image.Rect(0, 0, -dx, m.height))
+	}
+	switch {
+	case dy > 0:
+		dirty = append(dirty, image.Rect(x0, m.height-dy, x1, m.height))
+	case dy < 0:
+		dirty = append(dirty, image.Rect(x0, 0, x1, -dy))
+	}
+	m.dirty = dirty
+}
+
+func (m *Mandelbrot) Update() {
+	if m.autoIter {
+		m.followAutoZoom()
+		m.setMaxIterations(m.autoIterations())
+	}
+	if m.needsUpdate {
+		m.needsUpdate = false
+		m.dirty = []image.Rectangle{image.Rect(0, 0, m.width, m.height)}
+	}
+	if len(m.dirty) == 0 {
+		return
+	}
+	dirty := m.dirty
+	m.dirty = nil
+
+	p := m.renderParams()
+	if p.UsesInverseIteration() {
+		// Inverse iteration draws the whole set at once and leaves nothing
+		// for tiles to hold or for auto iterations to measure
+		RenderInto(p, m.framebuffer, m.samples)
+		return
+	}
+
+	if m.tileCache != nil {
+		m.updateFromTiles(dirty)
+	} else {
+		RenderInto(p, m.framebuffer, m.samples, dirty...)
+	}
+
+	if m.autoIter {
+		m.adjustAutoIterations()
+	}
 }
 
-func (m *Mandelbrot) mandelbrot(x, y int, z complex128, exponent complex128, c complex128) MandelbrotPixel {
-	pixel := [4]byte{0, 0, 0, 255}
-	brot := MandelbrotPixel{X: x, Y: y, Color: pixel}
-	n := uint64(0)
+// updateFromTiles fills the dirty rectangles by sampling quadtree tiles,
+// computing only the tiles that aren't already in the cache.
+func (m *Mandelbrot) updateFromTiles(dirty []image.Rectangle) {
+	p := m.renderParams()
+	level := tileLevel(min(p.PixelSize()))
+	key := p.Key()
+
+	type tileSample struct {
+		pixel  int
+		key    TileKey
+		sample int
+	}
+	var lookups []tileSample
+	tiles := make(map[TileKey][]Sample)
+	for _, r := range dirty {
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				tx, ty, sample := tileLocate(level, p.Point(x, y))
+				tk := TileKey{Params: key, Level: level, X: tx, Y: ty}
+				lookups = append(lookups, tileSample{pixel: y*m.width + x, key: tk, sample: sample})
+				tiles[tk] = nil
+			}
+		}
+	}
 
-	for n < m.maxIterations && cmplx.Abs(z) < 2 {
-		z = cmplx.Pow(z, exponent) + c
-		n++
+	var missing []TileKey
+	for tk := range tiles {
+		if samples, ok := m.tileCache.Get(tk); ok {
+			tiles[tk] = samples
+		} else {
+			missing = append(missing, tk)
+		}
 	}
 
-	if n == m.maxIterations {
-		return brot
+	computed := make([][]Sample, len(missing))
+	tileWG := sync.WaitGroup{}
+	for i, tk := range missing {
+		tileWG.Add(1)
+		go func(i int, tk TileKey) {
+			defer tileWG.Done()
+			samples := make([]Sample, tileSize*tileSize)
+			for j := range samples {
+				samples[j] = p.Sample(tilePoint(tk.Level, tk.X, tk.Y, j))
+			}
+			m.tileCache.Put(tk, samples)
+			computed[i] = samples
+		}(i, tk)
+	}
+	tileWG.Wait()
+	for i, tk := range missing {
+		tiles[tk] = computed[i]
 	}
 
-	brot.Color = m.palette.Color(n, m.maxIterations)
-	return brot
+	for _, l := range lookups {
+		sample := tiles[l.key][l.sample]
+		color := p.Color(sample)
+		copy(m.framebuffer.Pix[l.pixel*4:l.pixel*4+4], color[:])
+		m.samples[l.pixel] = sample
+	}
 }
 
 func (m *Mandelbrot) Relayout(width, height int) {
@@ -247,6 +583,8 @@ func (m *Mandelbrot) Relayout(width, height int) {
 	}
 	m.width = width
 	m.height = height
-	m.framebuffer = make([]byte, width*height*4)
+	m.framebuffer = image.NewRGBA(image.Rect(0, 0, width, height))
+	m.samples = make([]Sample, width*height)
+	m.dirty = nil
 	m.needsUpdate = true
 }
@@ -0,0 +1,224 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+const (
+	// misiurewiczNewtonSteps bounds the refinement of a Misiurewicz point
+	misiurewiczNewtonSteps = 128
+	// misiurewiczGuardBits is the precision kept beyond what the view needs
+	misiurewiczGuardBits = 64
+)
+
+// Misiurewicz is a point whose critical orbit lands on a cycle of length
+// Period after Preperiod iterations without being periodic itself. These
+// are the centers of the spirals and branch points of the set.
+type Misiurewicz struct {
+	// Center is the point rounded to a complex128, which views use. It
+	// holds about 16 significant digits, so views deeper than around
+	// 1e-16 of its magnitude can't be centered on it exactly.
+	Center    complex128
+	Preperiod int
+	Period    int
+	// Real and Imag keep the full precision of the Newton iteration
+	Real, Imag *big.Float
+}
+
+func (m Misiurewicz) String() string {
+	sign := "+"
+	if m.Imag.Sign() < 0 {
+		sign = ""
+	}
+	return fmt.Sprintf("M%d,%d at %s%s%si", m.Preperiod, m.Period, m.Real.Text('g', -1), sign, m.Imag.Text('g', -1))
+}
+
+// CanFindMisiurewicz reports whether Misiurewicz points can be located with
+// these params. The high precision iteration only knows z^d + c for whole d,
+// and Misiurewicz points are preperiodic points of the critical orbit, which
+// starts at 0.
+func (p *RenderParams) CanFindMisiurewicz() bool {
+	_, ok := integerDegree(&p.Params)
+	return ok && p.Fractal.Name() == FractalMandelbrot && !p.Julia && p.Plane == nil && p.StartingZ == 0
+}
+
+// FindMisiurewicz locates the Misiurewicz point of the given preperiod and
+// period that Newton's method reaches from a point. Newton runs with enough
+// bits for the view's scale, starting from the complex128 point, and the
+// preperiod and period found are checked in double precision.
+func (p *RenderParams) FindMisiurewicz(point complex128, preperiod, period int) (Misiurewicz, error) {
+	if !p.CanFindMisiurewicz() {
+		return Misiurewicz{}, fmt.Errorf("can't find Misiurewicz points of %s with exponent %v", p.Fractal.Name(), p.Params.Exponent)
+	}
+	if preperiod < 1 || period < 1 {
+		return Misiurewicz{}, fmt.Errorf("invalid preperiod %d and period %d", preperiod, period)
+	}
+	degree, _ := integerDegree(&p.Params)
+
+	// Enough bits to tell pixels apart, plus room for Newton to settle
+	prec := uint(misiurewiczGuardBits + max(0, int(-math.Log2(p.View.Scale))))
+	c := newBigComplex(prec, point)
+	z0 := newBigComplex(prec, p.StartingZ)
+	tolerance := new(big.Float).SetPrec(prec).SetMantExp(big.NewFloat(1), -int(prec)+misiurewiczGuardBits/2)
+
+	converged := false
+	for range misiurewiczNewtonSteps {
+		step, ok := misiurewiczStep(z0, c, degree, preperiod, period)
+		if !ok {
+			return Misiurewicz{}, fmt.Errorf("M%d,%d near %v did not converge", preperiod, period, point)
+		}
+		c = c.sub(step)
+		if step.abs().Cmp(new(big.Float).Mul(tolerance, maxOne(c.abs()))) <= 0 {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return Misiurewicz{}, fmt.Errorf("M%d,%d near %v did not converge", preperiod, period, point)
+	}
+
+	found := Misiurewicz{Preperiod: preperiod, Period: period, Real: c.re, Imag: c.im}
+	re, _ := c.re.Float64()
+	im, _ := c.im.Float64()
+	found.Center = complex(re, im)
+	if actualPreperiod, actualPeriod := p.misiurewiczPeriods(found.Center, preperiod+period); actualPreperiod != preperiod || actualPeriod != period {
+		return found, fmt.Errorf("Newton reached M%d,%d instead of M%d,%d", actualPreperiod, actualPeriod, preperiod, period)
+	}
+	return found, nil
+}
+
+// misiurewiczStep is the Newton step for
+//
+//	(z[k+p] - z[k]) / (z[k+p-1] - z[k-1]) = 0
+//
+// where dividing by the lower preperiod keeps Newton from settling on
+// points of a smaller preperiod
+func misiurewiczStep(z0, c bigComplex, degree, preperiod, period int) (bigComplex, bool) {
+	prec := c.re.Prec()
+	z, dz := z0, newBigComplex(prec, 0)
+	one := newBigComplex(prec, 1)
+	d := newBigComplex(prec, complex(float64(degree), 0))
+
+	// Orbit and derivative at the four iterations the function needs
+	var zs, dzs [4]bigComplex
+	marks := [4]int{preperiod - 1, preperiod, preperiod + period - 1, preperiod + period}
+	for n := 0; n <= preperiod+period; n++ {
+		for i, mark := range marks {
+			if n == mark {
+				zs[i], dzs[i] = z, dz
+			}
+		}
+		// dz' = d z^(d-1) dz + 1, z' = z^d + c
+		zd1 := z.pow(degree - 1)
+		dz = d.mul(zd1).mul(dz).add(one)
+		z = zd1.mul(z).add(c)
+		if !z.finite() || !dz.finite() {
+			return bigComplex{}, false
+		}
+	}
+
+	f := zs[3].sub(zs[1])
+	df := dzs[3].sub(dzs[1])
+	g := zs[2].sub(zs[0])
+	dg := dzs[2].sub(dzs[0])
+	// (f/g)/(f/g)' = f g / (f' g - f g')
+	den := df.mul(g).sub(f.mul(dg))
+	if den.isZero() {
+		return bigComplex{}, false
+	}
+	return f.mul(g).quo(den), true
+}
+
+// misiurewiczPeriods measures the preperiod and period of the critical
+// orbit of c in double precision, looking at up to limit iterations
+func (p *RenderParams) misiurewiczPeriods(c complex128, limit int) (preperiod, period int) {
+	orbit := make([]complex128, 0, limit+1)
+	z := p.StartingZ
+	for range limit + 1 {
+		orbit = append(orbit, z)
+		z = p.Fractal.Iterate(z, c, &p.Params)
+	}
+	// The first repeat of the orbit gives both numbers at once
+	scale := 0.0
+	for _, z := range orbit {
+		scale = max(scale, cmplx.Abs(z))
+	}
+	tolerance := 1e-9 * max(1, scale)
+	for end := 1; end < len(orbit); end++ {
+		for start := range end {
+			if cmplx.Abs(orbit[end]-orbit[start]) < tolerance {
+				return start, end - start
+			}
+		}
+	}
+	return 0, 0
+}
+
+func maxOne(f *big.Float) *big.Float {
+	if f.Cmp(big.NewFloat(1)) < 0 {
+		return big.NewFloat(1)
+	}
+	return f
+}
+
+// bigComplex is a complex number with big.Float parts. Operations return
+// new values at the precision of the receiver.
+type bigComplex struct {
+	re, im *big.Float
+}
+
+func newBigComplex(prec uint, z complex128) bigComplex {
+	return bigComplex{
+		re: new(big.Float).SetPrec(prec).SetFloat64(real(z)),
+		im: new(big.Float).SetPrec(prec).SetFloat64(imag(z)),
+	}
+}
+
+func (a bigComplex) float() *big.Float {
+	return new(big.Float).SetPrec(a.re.Prec())
+}
+
+func (a bigComplex) add(b bigComplex) bigComplex {
+	return bigComplex{re: a.float().Add(a.re, b.re), im: a.float().Add(a.im, b.im)}
+}
+
+func (a bigComplex) sub(b bigComplex) bigComplex {
+	return bigComplex{re: a.float().Sub(a.re, b.re), im: a.float().Sub(a.im, b.im)}
+}
+
+func (a bigComplex) mul(b bigComplex) bigComplex {
+	re := a.float().Sub(a.float().Mul(a.re, b.re), a.float().Mul(a.im, b.im))
+	im := a.float().Add(a.float().Mul(a.re, b.im), a.float().Mul(a.im, b.re))
+	return bigComplex{re: re, im: im}
+}
+
+func (a bigComplex) quo(b bigComplex) bigComplex {
+	den := a.float().Add(a.float().Mul(b.re, b.re), a.float().Mul(b.im, b.im))
+	re := a.float().Add(a.float().Mul(a.re, b.re), a.float().Mul(a.im, b.im))
+	im := a.float().Sub(a.float().Mul(a.im, b.re), a.float().Mul(a.re, b.im))
+	return bigComplex{re: re.Quo(re, den), im: im.Quo(im, den)}
+}
+
+func (a bigComplex) pow(n int) bigComplex {
+	out := bigComplex{re: a.float().SetInt64(1), im: a.float()}
+	for range n {
+		out = out.mul(a)
+	}
+	return out
+}
+
+func (a bigComplex) abs() *big.Float {
+	sq := a.float().Add(a.float().Mul(a.re, a.re), a.float().Mul(a.im, a.im))
+	return sq.Sqrt(sq)
+}
+
+func (a bigComplex) isZero() bool {
+	return a.re.Sign() == 0 && a.im.Sign() == 0
+}
+
+func (a bigComplex) finite() bool {
+	return !a.re.IsInf() && !a.im.IsInf()
+}
@@ -0,0 +1,214 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+)
+
+const (
+	// nucleusNewtonSteps bounds the refinement of a nucleus
+	nucleusNewtonSteps = 64
+	// nucleusGrid is how many cells across the view is split into when
+	// scanning it for minibrots
+	nucleusGrid = 8
+	// nucleusFrame is how much room a framed minibrot gets around it,
+	// relative to its size
+	nucleusFrame = 1.3
+	// nucleusTolerance is how close an orbit must come back to the critical
+	// point to count as periodic
+	nucleusTolerance = 1e-9
+)
+
+// Nucleus is the center of a hyperbolic component, the point whose orbit
+// comes back to the critical point after Period iterations. For periods
+// past one these are the minibrots.
+type Nucleus struct {
+	Center complex128
+	Period int
+	// Size is the scale and rotation of the minibrot relative to the whole
+	// set, so that Center + Size*z maps the set onto the minibrot
+	Size complex128
+}
+
+// View frames the minibrot around the nucleus
+func (n Nucleus) View() View {
+	// The set reaches from -2 to 0.25 around its nucleus, so the middle of a
+	// minibrot sits a bit off its nucleus
+	return View{
+		Center: n.Center + n.Size*complex(-0.75, 0),
+		Scale:  nucleusFrame * cmplx.Abs(n.Size),
+	}
+}
+
+func (n Nucleus) String() string {
+	return fmt.Sprintf("period %d at %v, size %.3g", n.Period, n.Center, cmplx.Abs(n.Size))
+}
+
+// CanFindNuclei reports whether nuclei can be located with these params.
+// Newton's method needs a formula that is analytic in c, and nuclei only
+// exist in the parameter plane. Nuclei are where the critical orbit comes
+// back to 0, so the orbit has to start there too.
+func (p *RenderParams) CanFindNuclei() bool {
+	_, ok := p.Fractal.(Differentiable)
+	return ok && !p.Julia && p.Plane == nil && p.StartingZ == 0
+}
+
+// FindNucleus locates the nucleus of lowest period within radius of a point
+func (p *RenderParams) FindNucleus(point complex128, radius float64) (Nucleus, error) {
+	if !p.CanFindNuclei() {
+		return Nucleus{}, fmt.Errorf("can't find nuclei of %s in this mode", p.Fractal.Name())
+	}
+	period := p.boxPeriod(point, radius)
+	if period == 0 {
+		return Nucleus{}, fmt.Errorf("no period found within %d iterations", p.MaxIterations)
+	}
+	center, ok := p.nucleusNewton(point, period)
+	if !ok {
+		return Nucleus{}, fmt.Errorf("period %d nucleus near %v did not converge", period, point)
+	}
+	// Newton can land on a nucleus whose period divides the one searched for
+	period = p.nucleusPeriod(center, period)
+	return Nucleus{Center: center, Period: period, Size: p.nucleusSize(center, period)}, nil
+}
+
+// FindNuclei scans the view for minibrots, lowest periods and biggest
+// sizes first
+func (p *RenderParams) FindNuclei() []Nucleus {
+	if !p.CanFindNuclei() {
+		return nil
+	}
+	vp := p.viewport()
+	cell := (vp[2] - vp[0]) / nucleusGrid
+	rows := int(math.Ceil((vp[3] - vp[1]) / cell))
+	pw, _ := p.PixelSize()
+
+	var nuclei []Nucleus
+	for row := range rows {
+		for col := range nucleusGrid {
+			point := complex(vp[0]+(float64(col)+0.5)*cell, vp[1]+(float64(row)+0.5)*cell)
+			n, err := p.FindNucleus(point, cell/2)
+			if err != nil || n.Period < 2 {
+				continue
+			}
+			if real(n.Center) < vp[0] || real(n.Center) > vp[2] || imag(n.Center) < vp[1] || imag(n.Center) > vp[3] {
+				continue
+			}
+			duplicate := slices.ContainsFunc(nuclei, func(o Nucleus) bool {
+				return o.Period == n.Period && cmplx.Abs(o.Center-n.Center) < pw
+			})
+			if !duplicate {
+				nuclei = append(nuclei, n)
+			}
+		}
+	}
+	slices.SortFunc(nuclei, func(a, b Nucleus) int {
+		if a.Period != b.Period {
+			return a.Period - b.Period
+		}
+		return -cmpFloat(cmplx.Abs(a.Size), cmplx.Abs(b.Size))
+	})
+	return nuclei
+}
+
+func cmpFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// boxPeriod iterates the corners of a square and returns the first
+// iteration at which their images surround the origin, which is the lowest
+// period of the nuclei inside it. It returns 0 if the square escapes or no
+// period is found.
+func (p *RenderParams) boxPeriod(point complex128, radius float64) int {
+	r := complex(radius, 0)
+	ri := complex(0, radius)
+	corners := [4]complex128{point - r - ri, point + r - ri, point + r + ri, point - r + ri}
+	z := [4]complex128{p.StartingZ, p.StartingZ, p.StartingZ, p.StartingZ}
+	bailout := p.Fractal.Bailout(&p.Params)
+	for n := uint64(1); n <= p.MaxIterations; n++ {
+		for i := range z {
+			z[i] = p.Fractal.Iterate(z[i], corners[i], &p.Params)
+			if cmplx.Abs(z[i]) >= bailout {
+				return 0
+			}
+		}
+		if surroundsOrigin(z) {
+			return int(n)
+		}
+	}
+	return 0
+}
+
+// surroundsOrigin counts the crossings of a ray from the origin with the
+// edges of the polygon
+func surroundsOrigin(polygon [4]complex128) bool {
+	inside := false
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if (imag(a) > 0) != (imag(b) > 0) {
+			x := real(a) + (0-imag(a))*(real(b)-real(a))/(imag(b)-imag(a))
+			if x > 0 {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// nucleusNewton solves z_period(c) = 0 for c starting from a guess
+func (p *RenderParams) nucleusNewton(c complex128, period int) (complex128, bool) {
+	for range nucleusNewtonSteps {
+		z, dc := p.StartingZ, complex(0, 0)
+		for range period {
+			dc = derivative(p.Fractal, z, c, &p.Params)*dc + 1
+			z = p.Fractal.Iterate(z, c, &p.Params)
+		}
+		if dc == 0 || cmplxIsBad(z) || cmplxIsBad(dc) {
+			return c, false
+		}
+		step := z / dc
+		c -= step
+		if cmplx.Abs(step) <= 1e-15*max(1, cmplx.Abs(c)) {
+			return c, true
+		}
+	}
+	// Deep down float64 runs out of precision before the steps get small,
+	// which still leaves a usable nucleus
+	return c, !cmplxIsBad(c)
+}
+
+// nucleusPeriod returns the first iteration that brings the orbit of a
+// nucleus back to the critical point, which is a divisor of period
+func (p *RenderParams) nucleusPeriod(c complex128, period int) int {
+	z := p.StartingZ
+	for n := 1; n < period; n++ {
+		z = p.Fractal.Iterate(z, c, &p.Params)
+		if period%n == 0 && cmplx.Abs(z-p.StartingZ) < nucleusTolerance {
+			return n
+		}
+	}
+	return period
+}
+
+// nucleusSize estimates the size of the minibrot around a nucleus from the
+// multipliers along its orbit
+func (p *RenderParams) nucleusSize(c complex128, period int) complex128 {
+	z, l, b := p.StartingZ, complex(1, 0), complex(1, 0)
+	for range period - 1 {
+		z = p.Fractal.Iterate(z, c, &p.Params)
+		l *= derivative(p.Fractal, z, c, &p.Params)
+		b += 1 / l
+	}
+	degree := p.Params.Exponent
+	if l == 0 || b == 0 || cmplx.Abs(degree-1) < 1e-9 {
+		return 0
+	}
+	return 1 / (b * cmplx.Pow(l, degree/(degree-1)))
+}
@@ -0,0 +1,69 @@
+package mandelbrot
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	// orbitMaxPeriod is the longest cycle an orbit is checked for
+	orbitMaxPeriod = 1024
+	// orbitPeriodTolerance is how close, relative to |z|, two points of the
+	// orbit must be to count as the same point of a cycle
+	orbitPeriodTolerance = 1e-9
+)
+
+// Orbit is the path a point takes under iteration
+type Orbit struct {
+	// Points starts with the starting z and holds at most the number of
+	// points asked for, even if the orbit went on for longer
+	Points []complex128
+	// Iterations is how many iterations the orbit ran for in total
+	Iterations uint64
+	Escaped    bool
+	// Period is the length of the cycle the orbit settled into, or 0 if it
+	// escaped or didn't settle within MaxIterations
+	Period int
+}
+
+// Orbit iterates a point in the view like Sample does, keeping the first
+// limit points of its path
+func (p *RenderParams) Orbit(point complex128, limit int) Orbit {
+	z, c := p.StartingPoint(point)
+	bailout := p.bailout()
+
+	orbit := Orbit{Points: make([]complex128, 0, min(limit, int(min(p.MaxIterations+1, math.MaxInt32))))}
+	// The tail of the orbit is kept in a ring to look for a cycle at the end
+	tail := make([]complex128, orbitMaxPeriod+1)
+	n := uint64(0)
+	for {
+		if len(orbit.Points) < limit {
+			orbit.Points = append(orbit.Points, z)
+		}
+		tail[n%uint64(len(tail))] = z
+		if n >= p.MaxIterations || cmplx.Abs(z) >= bailout {
+			break
+		}
+		z = p.iterate(n, z, c)
+		n++
+	}
+	orbit.Iterations = n
+	orbit.Escaped = p.Escaped(Sample{Iterations: n})
+	if !orbit.Escaped {
+		orbit.Period = period(tail, n)
+	}
+	return orbit
+}
+
+// period finds the shortest cycle ending at the n-th point of the ring
+func period(tail []complex128, n uint64) int {
+	size := uint64(len(tail))
+	last := tail[n%size]
+	tolerance := orbitPeriodTolerance * max(1, cmplx.Abs(last))
+	for k := uint64(1); k < size && k <= n; k++ {
+		if cmplx.Abs(last-tail[(n-k)%size]) < tolerance {
+			return int(k)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package mandelbrot
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Plane is a 2D slice through the 4D space of starting z and c, each
+// written as (z.re, z.im, c.re, c.im). The point x+yi of the view maps to
+// Origin + x·U + y·V. The Mandelbrot plane varies c, the Julia plane z.
+type Plane struct {
+	Origin [4]float64
+	U, V   [4]float64
+}
+
+// MandelbrotPlane varies c with z fixed
+func MandelbrotPlane(z complex128) Plane {
+	return RotatedPlane(z, 0, 0)
+}
+
+// JuliaPlane varies z with c fixed
+func JuliaPlane(c complex128) Plane {
+	return RotatedPlane(0, c, 90)
+}
+
+// RotatedPlane turns from the Mandelbrot plane through z at 0 degrees to the
+// Julia plane of c at 90, rotating the real and imaginary parts together so
+// every plane in between is still a complex line
+func RotatedPlane(z, c complex128, degrees float64) Plane {
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	// Snap the ends so they match the fixed planes exactly
+	if math.Abs(sin) < 1e-12 {
+		sin = 0
+	} else if math.Abs(cos) < 1e-12 {
+		cos = 0
+	}
+	return Plane{
+		Origin: [4]float64{cos * real(z), cos * imag(z), sin * real(c), sin * imag(c)},
+		U:      [4]float64{sin, 0, cos, 0},
+		V:      [4]float64{0, sin, 0, cos},
+	}
+}
+
+// Map returns the starting z and c of a point in the view
+func (pl *Plane) Map(point complex128) (z, c complex128) {
+	x, y := real(point), imag(point)
+	var q [4]float64
+	for i := range q {
+		q[i] = pl.Origin[i] + x*pl.U[i] + y*pl.V[i]
+	}
+	return complex(q[0], q[1]), complex(q[2], q[3])
+}
+
+// derivative returns how the starting z and c change along the view's real
+// axis. For planes that are complex lines this is the complex derivative.
+func (pl *Plane) derivative() (dz, dc complex128) {
+	return complex(pl.U[0], pl.U[1]), complex(pl.U[2], pl.U[3])
+}
+
+func (pl Plane) String() string {
+	return fmt.Sprintf("%v+x%v+y%v", pl.Origin, pl.U, pl.V)
+}
+
+// ParseVector reads a point of the space of starting z and c written as
+// four comma separated numbers, z.re,z.im,c.re,c.im
+func ParseVector(s string) ([4]float64, error) {
+	var v [4]float64
+	parts := strings.Split(s, ",")
+	if len(parts) != len(v) {
+		return v, fmt.Errorf("vector %q doesn't have %d parts", s, len(v))
+	}
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return v, fmt.Errorf("invalid vector %q: %w", s, err)
+		}
+		v[i] = f
+	}
+	return v, nil
+}
+
+// FormatVector writes a vector the way ParseVector reads it
+func FormatVector(v [4]float64) string {
+	parts := make([]string, len(v))
+	for i, f := range v {
+		parts[i] = strconv.FormatFloat(f, 'g', -1, 64)
+	}
+	return strings.Join(parts, ",")
+}
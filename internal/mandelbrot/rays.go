@@ -0,0 +1,144 @@
+package mandelbrot
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/cmplx"
+)
+
+const (
+	// raySharpness is how many points are traced per level of depth
+	raySharpness = 8
+	// rayEscapeRadius is where rays start, far enough out that the
+	// potential is close to log|z|
+	rayEscapeRadius = 65536
+	// rayNewtonSteps bounds the refinement of each point of a ray
+	rayNewtonSteps = 64
+	// rayLandingFrame is how much of the last traced level a landing view
+	// shows around the landing point
+	rayLandingFrame = 4
+)
+
+var errRayDiverged = errors.New("ray tracing diverged")
+
+// ParseAngle reads an external angle in turns, either as a fraction such as
+// 1/3 or as a decimal, and reduces it to [0, 1)
+func ParseAngle(s string) (*big.Rat, error) {
+	angle, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return nil, fmt.Errorf("invalid angle %q", s)
+	}
+	return wrapAngle(angle), nil
+}
+
+// wrapAngle reduces an angle in turns to [0, 1)
+func wrapAngle(angle *big.Rat) *big.Rat {
+	whole := new(big.Int).Div(angle.Num(), angle.Denom())
+	return angle.Sub(angle, new(big.Rat).SetInt(whole))
+}
+
+// CanTraceRays reports whether external rays can be traced with these
+// params. Tracing follows the Böttcher coordinate of z^d + c for whole d,
+// and external rays belong to the critical orbit, which starts at 0.
+func (p *RenderParams) CanTraceRays() bool {
+	_, ok := integerDegree(&p.Params)
+	return ok && p.Fractal.Name() == FractalMandelbrot && p.Plane == nil && (p.Julia || p.StartingZ == 0)
+}
+
+// TraceRay follows the external ray of an angle in turns inwards from the
+// escape radius for up to depth levels, or until float64 can't tell its
+// points apart. In Julia mode it traces the dynamic ray of StartingC.
+func (p *RenderParams) TraceRay(angle *big.Rat, depth int) ([]complex128, error) {
+	if !p.CanTraceRays() {
+		return nil, fmt.Errorf("can't trace rays of %s with exponent %v", p.Fractal.Name(), p.Params.Exponent)
+	}
+	degree, _ := integerDegree(&p.Params)
+	angle = new(big.Rat).Set(angle)
+	turns, _ := angle.Float64()
+
+	point := cmplx.Rect(rayEscapeRadius, 2*math.Pi*turns)
+	points := []complex128{point}
+	for level := range depth {
+		for step := range raySharpness {
+			// Each level goes from the escape radius to its d-th root, as
+			// one more iteration maps it back out
+			r := math.Pow(rayEscapeRadius, math.Pow(1/float64(degree), (float64(step)+0.5)/raySharpness))
+			target := cmplx.Rect(r, 2*math.Pi*turns)
+
+			next, ok := p.rayNewton(point, target, degree, level+1)
+			if !ok {
+				if len(points) > 1 {
+					return points, nil
+				}
+				return nil, errRayDiverged
+			}
+			if cmplx.Abs(next-point) <= 1e-15*max(1, cmplx.Abs(next)) {
+				return points, nil
+			}
+			point = next
+			points = append(points, point)
+		}
+		// One level deeper the ray is seen through one more iteration,
+		// which multiplies its angle by the degree
+		angle = wrapAngle(angle.Mul(angle, new(big.Rat).SetInt64(int64(degree))))
+		turns, _ = angle.Float64()
+	}
+	return points, nil
+}
+
+// rayNewton solves z_n = target for the varying point of the plane
+func (p *RenderParams) rayNewton(point, target complex128, degree, n int) (complex128, bool) {
+	d := complex(float64(degree), 0)
+	for range rayNewtonSteps {
+		var z, dz complex128
+		if p.Julia {
+			z, dz = point, 1
+		} else {
+			z, dz = p.StartingZ, 0
+		}
+		for range n {
+			zd1 := ipow(z, degree-1)
+			if p.Julia {
+				dz = d * zd1 * dz
+				z = zd1*z + p.StartingC
+			} else {
+				dz = d*zd1*dz + 1
+				z = zd1*z + point
+			}
+		}
+		if dz == 0 || cmplxIsBad(z) || cmplxIsBad(dz) {
+			return point, false
+		}
+		step := (z - target) / dz
+		point -= step
+		if cmplxIsBad(point) {
+			return point, false
+		}
+		if cmplx.Abs(step) <= 1e-15*max(1, cmplx.Abs(point)) {
+			break
+		}
+	}
+	return point, true
+}
+
+// ipow raises z to a small whole power
+func ipow(z complex128, n int) complex128 {
+	out := complex(1, 0)
+	for range n {
+		out *= z
+	}
+	return out
+}
+
+// LandingView frames where a traced ray lands, sized by how far the ray
+// moved over its last level
+func LandingView(points []complex128) View {
+	end := points[len(points)-1]
+	from := points[max(0, len(points)-1-raySharpness)]
+	return View{
+		Center: end,
+		Scale:  max(rayLandingFrame*cmplx.Abs(end-from), 1e-13*max(1, cmplx.Abs(end))),
+	}
+}
@@ -0,0 +1,427 @@
+package mandelbrot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"math/cmplx"
+	"runtime"
+	"sync"
+)
+
+// RenderParams fully describes an image. Rendering has no side effects, so
+// the same params always produce the same pixels no matter who asks for them.
+type RenderParams struct {
+	Fractal Fractal
+	Params  Params
+	View    View
+	// Rotation turns the view counterclockwise about its center, in degrees
+	Rotation      float64
+	Width, Height int
+	Palette       *Palette
+	MaxIterations uint64
+	Julia         bool
+	StartingZ     complex128
+	StartingC     complex128
+	Lighting      Lighting
+	Contours      Contours
+	Coloring      Coloring
+	JuliaMethod   JuliaMethod
+	// Plane, when set, replaces the Mandelbrot or Julia plane with any
+	// slice through the space of starting z and c, and Julia is ignored
+	Plane *Plane
+	// TrackDerivative asks for Sample.Derivative even when nothing in the
+	// coloring needs it, for instance to read distance estimates
+	TrackDerivative bool
+}
+
+// Sample is the outcome of iterating a single point
+type Sample struct {
+	Iterations uint64
+	// Z is the last value of the orbit, just past the bailout if it escaped
+	Z complex128
+	// Derivative is dz/dc, or dz/dz0 for Julia sets, at the last iteration.
+	// It is only tracked when something needs it, see TracksDerivative.
+	Derivative complex128
+	// Average and PreviousAverage are the coloring's average along the
+	// orbit with and without its last point. They are only tracked when the
+	// coloring uses them.
+	Average, PreviousAverage float64
+}
+
+// DefaultRenderParams returns the params of the explorer's starting view
+func DefaultRenderParams(width, height int) RenderParams {
+	fractal, _ := LookupFractal(FractalMandelbrot)
+	return RenderParams{
+		Fractal:       fractal,
+		Params:        DefaultParams(fractal),
+		View:          fractal.DefaultView(),
+		Width:         width,
+		Height:        height,
+		Palette:       NewPalette(PaletteModeSimpleRainbow),
+		MaxIterations: 1000,
+		StartingZ:     complex(0, 0),
+		StartingC:     complex(-0.63, 0.34),
+		Lighting:      DefaultLighting(),
+		Contours:      DefaultContours(),
+		Coloring:      DefaultColoring(),
+		JuliaMethod:   JuliaMethodEscapeTime,
+	}
+}
+
+// Render allocates an image and renders the whole view into it
+func Render(p RenderParams) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+	RenderInto(p, img, nil)
+	return img
+}
+
+// RenderInto renders the given rectangles, or the whole view if there are
+// none, into dst. dst shares the coordinate space of the view, so a
+// sub-image or a strip with a non-zero origin only receives its own part.
+// When samples is not nil it receives the sample behind each pixel, indexed
+// row by row from the top left of dst.
+func RenderInto(p RenderParams, dst *image.RGBA, samples []Sample, rects ...image.Rectangle) {
+	if p.UsesInverseIteration() {
+		// Inverse iteration can't be limited to parts of the view
+		renderInverse(p, dst, samples)
+		return
+	}
+
+	bounds := dst.Bounds().Intersect(image.Rect(0, 0, p.Width, p.Height))
+	if len(rects) == 0 {
+		rects = []image.Rectangle{bounds}
+	}
+
+	p.renderSpans(bounds, rects, func(x, y int, sample Sample) {
+		color := p.Color(sample)
+		i := dst.PixOffset(x, y)
+		copy(dst.Pix[i:i+4], color[:])
+		if samples != nil {
+			samples[(y-dst.Rect.Min.Y)*dst.Rect.Dx()+x-dst.Rect.Min.X] = sample
+		}
+	})
+}
+
+// Render64 allocates a 16 bit image and renders the whole view into it
+func Render64(p RenderParams) *image.RGBA64 {
+	img := image.NewRGBA64(image.Rect(0, 0, p.Width, p.Height))
+	RenderInto64(p, img)
+	return img
+}
+
+// RenderInto64 is RenderInto for 16 bit images. Inverse iteration only
+// marks the pixels it hits, so it is rendered at 8 bits and widened.
+func RenderInto64(p RenderParams, dst *image.RGBA64, rects ...image.Rectangle) {
+	bounds := dst.Bounds().Intersect(image.Rect(0, 0, p.Width, p.Height))
+	if p.UsesInverseIteration() {
+		img := image.NewRGBA(bounds)
+		renderInverse(p, img, nil)
+		draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+		return
+	}
+	if len(rects) == 0 {
+		rects = []image.Rectangle{bounds}
+	}
+	p.renderSpans(bounds, rects, func(x, y int, sample Sample) {
+		color := p.Color16(sample)
+		i := dst.PixOffset(x, y)
+		for c, v := range color {
+			binary.BigEndian.PutUint16(dst.Pix[i+2*c:], v)
+		}
+	})
+}
+
+// renderSpans samples the pixels of the rectangles within bounds on every
+// CPU, handing each sample to pixel
+func (p *RenderParams) renderSpans(bounds image.Rectangle, rects []image.Rectangle, pixel func(x, y int, sample Sample)) {
+	type span struct {
+		y, x0, x1 int
+	}
+	spans := make(chan span, runtime.NumCPU())
+	wg := sync.WaitGroup{}
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range spans {
+				for x := s.x0; x < s.x1; x++ {
+					pixel(x, s.y, p.Sample(p.Point(x, s.y)))
+				}
+			}
+		}()
+	}
+	for _, r := range rects {
+		r = r.Intersect(bounds)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			spans <- span{y: y, x0: r.Min.X, x1: r.Max.X}
+		}
+	}
+	close(spans)
+	wg.Wait()
+}
+
+func (p *RenderParams) viewport() [4]float64 {
+	return [4]float64{
+		boundMinX*p.View.Scale + real(p.View.Center),
+		boundMinY*p.View.Scale + imag(p.View.Center),
+		boundMaxX*p.View.Scale + real(p.View.Center),
+		boundMaxY*p.View.Scale + imag(p.View.Center),
+	}
+}
+
+// Point maps a pixel to the complex plane
+func (p *RenderParams) Point(x, y int) complex128 {
+	vp := p.viewport()
+
+	real := float64(x)/float64(p.Width)*vp[2] + (1-float64(x)/float64(p.Width))*vp[0]
+	imag := float64(y)/float64(p.Height)*vp[3] + (1-float64(y)/float64(p.Height))*vp[1]
+
+	return p.rotate(complex(real, imag), p.Rotation)
+}
+
+// rotate turns a point about the middle of the image by degrees
+func (p *RenderParams) rotate(point complex128, degrees float64) complex128 {
+	if degrees == 0 {
+		return point
+	}
+	vp := p.viewport()
+	middle := complex((vp[0]+vp[2])/2, (vp[1]+vp[3])/2)
+	return middle + (point-middle)*cmplx.Rect(1, degrees*math.Pi/180)
+}
+
+// Pixel maps a point in the complex plane to the pixel containing it
+func (p *RenderParams) Pixel(point complex128) (x, y int) {
+	vp := p.viewport()
+	point = p.rotate(point, -p.Rotation)
+
+	x = int((real(point)-vp[0])/(vp[2]-vp[0])*float64(p.Width)) + 1
+	y = int((imag(point)-vp[1])/(vp[3]-vp[1])*float64(p.Height)) + 1
+
+	return
+}
+
+// PixelSize is the width and height of a pixel in the complex plane
+func (p *RenderParams) PixelSize() (width, height float64) {
+	vp := p.viewport()
+	return (vp[2] - vp[0]) / float64(p.Width), (vp[3] - vp[1]) / float64(p.Height)
+}
+
+// StartingPoint returns the initial z and the c for a point in the view
+func (p *RenderParams) StartingPoint(point complex128) (z, c complex128) {
+	if p.Plane != nil {
+		return p.Plane.Map(point)
+	}
+	if p.Julia {
+		return point, p.StartingC
+	}
+	return p.StartingZ, point
+}
+
+// Sample iterates the orbit belonging to a point in the view
+func (p *RenderParams) Sample(point complex128) Sample {
+	z, c := p.StartingPoint(point)
+	n := uint64(0)
+	bailout := p.bailout()
+
+	if !p.TracksDerivative() || p.Coloring.UsesAverage() {
+		for n < p.MaxIterations && cmplx.Abs(z) < bailout {
+			z = p.iterate(n, z, c)
+			n++
+		}
+		if p.Coloring.UsesAverage() && n < p.MaxIterations {
+			// Averaging is costly and the interior doesn't show it, so the
+			// orbit is only followed again once it is known to escape
+			return p.sampleAverage(p.StartingPoint(point))
+		}
+		return Sample{Iterations: n, Z: z}
+	}
+
+	dz, dc := p.derivativeStart()
+	for n < p.MaxIterations && cmplx.Abs(z) < bailout {
+		dz = p.derivative(n, z, c)*dz + dc
+		z = p.iterate(n, z, c)
+		n++
+	}
+	return Sample{Iterations: n, Z: z, Derivative: dz}
+}
+
+// sampleAverage is Sample for colorings that average along the orbit
+func (p *RenderParams) sampleAverage(z, c complex128) Sample {
+	n := uint64(0)
+	bailout := p.bailout()
+	tracksDerivative := p.TracksDerivative()
+	average := newOrbitAverage(&p.Coloring, z, c)
+
+	dz, dc := p.derivativeStart()
+	for n < p.MaxIterations && cmplx.Abs(z) < bailout {
+		if tracksDerivative {
+			dz = p.derivative(n, z, c)*dz + dc
+		}
+		z = p.iterate(n, z, c)
+		average.add(z, p.degree(n))
+		n++
+	}
+	s := Sample{Iterations: n, Z: z}
+	if tracksDerivative {
+		s.Derivative = dz
+	}
+	s.Average, s.PreviousAverage = average.averages()
+	return s
+}
+
+// derivativeStart returns how z0 and c change with the point of the view.
+// Julia sets vary z0 instead of c, so the derivative starts at 1 and gains
+// nothing from c each step.
+func (p *RenderParams) derivativeStart() (dz, dc complex128) {
+	switch {
+	case p.Plane != nil:
+		return p.Plane.derivative()
+	case p.Julia:
+		return 1, 0
+	default:
+		return 0, 1
+	}
+}
+
+// iterate advances z by iteration n of the orbit, counted from 0
+func (p *RenderParams) iterate(n uint64, z, c complex128) complex128 {
+	if h, ok := p.Fractal.(hybridFractal); ok && len(p.Params.Hybrid) > 0 {
+		f, params := h.step(n, &p.Params)
+		return f.Iterate(z, c, params)
+	}
+	return p.Fractal.Iterate(z, c, &p.Params)
+}
+
+// derivative returns df/dz of the formula iteration n applies at z
+func (p *RenderParams) derivative(n uint64, z, c complex128) complex128 {
+	if h, ok := p.Fractal.(hybridFractal); ok && len(p.Params.Hybrid) > 0 {
+		f, params := h.step(n, &p.Params)
+		return derivative(f, z, c, params)
+	}
+	return derivative(p.Fractal, z, c, &p.Params)
+}
+
+// degree is how fast orbits grow far from the origin at iteration n
+func (p *RenderParams) degree(n uint64) float64 {
+	if h, ok := p.Fractal.(hybridFractal); ok && len(p.Params.Hybrid) > 0 {
+		return h.degree(n, &p.Params)
+	}
+	return cmplx.Abs(p.Params.Exponent)
+}
+
+// bailout is the fractal's bailout radius, raised for colorings that need
+// the orbit to get further out
+func (p *RenderParams) bailout() float64 {
+	bailout := p.Fractal.Bailout(&p.Params)
+	if p.Coloring.UsesAverage() {
+		return max(bailout, averageBailout)
+	}
+	return bailout
+}
+
+// TracksDerivative reports whether samples need the orbit's derivative
+func (p *RenderParams) TracksDerivative() bool {
+	return p.TrackDerivative || p.Lighting.Enabled
+}
+
+// Escaped reports whether the sample's orbit left the bailout radius
+func (p *RenderParams) Escaped(s Sample) bool {
+	return s.Iterations < p.MaxIterations
+}
+
+// SmoothIterations interpolates the escape iteration using how far past the
+// bailout the orbit landed, removing the banding of whole iteration counts.
+// Samples that never escaped return MaxIterations.
+func (p *RenderParams) SmoothIterations(s Sample) float64 {
+	if !p.Escaped(s) {
+		return float64(p.MaxIterations)
+	}
+	// The last iteration's formula took the orbit past the bailout
+	degree := p.degree(max(s.Iterations, 1) - 1)
+	bailout := p.bailout()
+	abs := cmplx.Abs(s.Z)
+	if degree <= 1 || bailout <= 1 || abs <= 1 {
+		return float64(s.Iterations)
+	}
+	return float64(s.Iterations) + 1 - math.Log(math.Log(abs)/math.Log(bailout))/math.Log(degree)
+}
+
+// Distance estimates how far the sample's point is from the set, in the
+// units of the complex plane. It needs TrackDerivative and is 0 for samples
+// that never escaped.
+func (p *RenderParams) Distance(s Sample) float64 {
+	if !p.Escaped(s) {
+		return 0
+	}
+	abs := cmplx.Abs(s.Z)
+	der := cmplx.Abs(s.Derivative)
+	if der == 0 || abs <= 1 {
+		return 0
+	}
+	return abs * math.Log(abs) / der
+}
+
+func (p *RenderParams) Color(s Sample) [4]byte {
+	if s.Iterations == p.MaxIterations {
+		return [4]byte{0, 0, 0, 255}
+	}
+	var color [4]byte
+	if p.Coloring.UsesAverage() {
+		// Weighing the last two averages by how far past the bailout the
+		// orbit landed removes the bands between iterations
+		weight := min(1, max(0, p.SmoothIterations(s)-float64(s.Iterations)))
+		color = p.Palette.ColorAt(weight*s.Average + (1-weight)*s.PreviousAverage)
+	} else {
+		color = p.Palette.Color(s.Iterations, p.MaxIterations)
+	}
+	if p.Lighting.Enabled {
+		color = p.Lighting.shade(color, s.Z, s.Derivative)
+	}
+	if p.Contours.Enabled() {
+		color = p.Contours.draw(color, p.SmoothIterations(s), s.Z)
+	}
+	return color
+}
+
+// Color16 is Color at 16 bits per channel
+func (p *RenderParams) Color16(s Sample) [4]uint16 {
+	if s.Iterations == p.MaxIterations {
+		return [4]uint16{0, 0, 0, math.MaxUint16}
+	}
+	var color [4]uint16
+	if p.Coloring.UsesAverage() {
+		weight := min(1, max(0, p.SmoothIterations(s)-float64(s.Iterations)))
+		color = p.Palette.ColorAt16(weight*s.Average + (1-weight)*s.PreviousAverage)
+	} else {
+		color = p.Palette.Color16(s.Iterations, p.MaxIterations)
+	}
+	if p.Lighting.Enabled {
+		color = p.Lighting.shade16(color, s.Z, s.Derivative)
+	}
+	if p.Contours.Enabled() {
+		color = p.Contours.draw16(color, p.SmoothIterations(s), s.Z)
+	}
+	return color
+}
+
+// Key identifies everything besides the view and size that changes the
+// samples of a render
+func (p *RenderParams) Key() string {
+	key := fmt.Sprintf("%s|%v|%v|%d|derivative=%t", p.Fractal.Name(), p.Params.Exponent, p.Params.Values, p.MaxIterations, p.TracksDerivative())
+	if len(p.Params.Hybrid) > 0 {
+		key = fmt.Sprintf("%s|%s", key, FormatHybrid(p.Params.Hybrid))
+	}
+	if p.Coloring.UsesAverage() {
+		key = fmt.Sprintf("%s|%s|%v|%d", key, p.Coloring.Method, p.Coloring.StripeDensity, p.Coloring.Skip)
+	}
+	if p.Plane != nil {
+		return fmt.Sprintf("%s|plane|%v", key, *p.Plane)
+	}
+	if p.Julia {
+		return fmt.Sprintf("%s|julia|%v", key, p.StartingC)
+	}
+	return fmt.Sprintf("%s|%v", key, p.StartingZ)
+}
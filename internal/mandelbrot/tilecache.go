@@ -0,0 +1,221 @@
+package mandelbrot
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// tileSize is the number of samples along each edge of a tile
+	tileSize = 64
+	// tileRootSpan is the width of a level 0 tile in the complex plane.
+	// Each level halves it, so a tile's four children cover it exactly.
+	tileRootSpan = 4
+	// tileFormat is bumped whenever the on-disk encoding of a Sample changes
+	tileFormat = 3
+	// tileSampleBytes is the encoded size of one Sample
+	tileSampleBytes = 56
+)
+
+type TileKey struct {
+	Params string
+	Level  int
+	X, Y   int64
+}
+
+type tile struct {
+	key     TileKey
+	samples []Sample
+}
+
+// TileCache keeps computed tiles of samples in memory, evicting the
+// least recently used when full, and optionally mirrors them to disk so they
+// survive restarts.
+type TileCache struct {
+	mu       sync.Mutex
+	capacity int
+	dir      string
+	entries  map[TileKey]*list.Element
+	lru      *list.List
+}
+
+func NewTileCache(capacity int, dir string) (*TileCache, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("invalid tile cache capacity %d", capacity)
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create tile cache directory: %w", err)
+		}
+	}
+	return &TileCache{
+		capacity: capacity,
+		dir:      dir,
+		entries:  make(map[TileKey]*list.Element),
+		lru:      list.New(),
+	}, nil
+}
+
+func (c *TileCache) Get(key TileKey) ([]Sample, bool) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		c.mu.Unlock()
+		return elem.Value.(*tile).samples, true
+	}
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return nil, false
+	}
+	samples, err := c.load(key)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("failed to load tile from disk", "error", err)
+		}
+		return nil, false
+	}
+	c.add(key, samples)
+	return samples, true
+}
+
+func (c *TileCache) Put(key TileKey, samples []Sample) {
+	c.add(key, samples)
+	if c.dir == "" {
+		return
+	}
+	if err := c.store(key, samples); err != nil {
+		slog.Warn("failed to store tile on disk", "error", err)
+	}
+}
+
+func (c *TileCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *TileCache) add(key TileKey, samples []Sample) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*tile).samples = samples
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&tile{key: key, samples: samples})
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tile).key)
+	}
+}
+
+func (c *TileCache) path(key TileKey) string {
+	sum := sha256.Sum256([]byte(key.Params))
+	return filepath.Join(
+		c.dir,
+		fmt.Sprintf("v%d", tileFormat),
+		hex.EncodeToString(sum[:8]),
+		fmt.Sprintf("%d", key.Level),
+		fmt.Sprintf("%d_%d.tile", key.X, key.Y),
+	)
+}
+
+func (c *TileCache) load(key TileKey) ([]Sample, error) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != tileSize*tileSize*tileSampleBytes {
+		return nil, fmt.Errorf("tile %s has unexpected size %d", c.path(key), len(data))
+	}
+	samples := make([]Sample, tileSize*tileSize)
+	for i := range samples {
+		b := data[i*tileSampleBytes:]
+		samples[i] = Sample{
+			Iterations:      binary.LittleEndian.Uint64(b),
+			Z:               decodeComplex(b[8:]),
+			Derivative:      decodeComplex(b[24:]),
+			Average:         math.Float64frombits(binary.LittleEndian.Uint64(b[40:])),
+			PreviousAverage: math.Float64frombits(binary.LittleEndian.Uint64(b[48:])),
+		}
+	}
+	return samples, nil
+}
+
+func (c *TileCache) store(key TileKey, samples []Sample) error {
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data := make([]byte, len(samples)*tileSampleBytes)
+	for i, s := range samples {
+		b := data[i*tileSampleBytes:]
+		binary.LittleEndian.PutUint64(b, s.Iterations)
+		encodeComplex(b[8:], s.Z)
+		encodeComplex(b[24:], s.Derivative)
+		binary.LittleEndian.PutUint64(b[40:], math.Float64bits(s.Average))
+		binary.LittleEndian.PutUint64(b[48:], math.Float64bits(s.PreviousAverage))
+	}
+	// Write then rename so a concurrent reader never sees a partial tile
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func encodeComplex(b []byte, v complex128) {
+	binary.LittleEndian.PutUint64(b, math.Float64bits(real(v)))
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(imag(v)))
+}
+
+func decodeComplex(b []byte) complex128 {
+	return complex(
+		math.Float64frombits(binary.LittleEndian.Uint64(b)),
+		math.Float64frombits(binary.LittleEndian.Uint64(b[8:])),
+	)
+}
+
+// tileLevel picks the shallowest quadtree level whose sample spacing is no
+// coarser than the given pixel size.
+func tileLevel(pixelSize float64) int {
+	return max(0, int(math.Ceil(math.Log2(tileRootSpan/(tileSize*pixelSize)))))
+}
+
+func tileSpan(level int) float64 {
+	return math.Ldexp(tileRootSpan, -level)
+}
+
+// tileLocate finds the tile at the given level containing point, and the
+// index of the sample within it nearest to point.
+func tileLocate(level int, point complex128) (x, y int64, sample int) {
+	span := tileSpan(level)
+	fx := real(point) / span
+	fy := imag(point) / span
+	x = int64(math.Floor(fx))
+	y = int64(math.Floor(fy))
+	sx := min(tileSize-1, int((fx-float64(x))*tileSize))
+	sy := min(tileSize-1, int((fy-float64(y))*tileSize))
+	return x, y, sy*tileSize + sx
+}
+
+// tilePoint is the point in the complex plane sampled by index i of a tile
+func tilePoint(level int, x, y int64, i int) complex128 {
+	span := tileSpan(level)
+	spacing := span / tileSize
+	return complex(
+		float64(x)*span+(float64(i%tileSize)+0.5)*spacing,
+		float64(y)*span+(float64(i/tileSize)+0.5)*spacing,
+	)
+}
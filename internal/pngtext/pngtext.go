@@ -0,0 +1,250 @@
+package pngtext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	signature = "\x89PNG\r\n\x1a\n"
+	// headerSize is the signature and the IHDR chunk, which every PNG starts with
+	headerSize = len(signature) + 12 + 13
+	// maxTextSize bounds the text chunks read, as images can come from anywhere
+	maxTextSize = 16 << 20
+)
+
+// Text is a keyword and value stored in a PNG
+type Text struct {
+	Keyword string
+	Value   string
+	// UTF8 stores the value in an iTXt chunk. Otherwise it goes in a tEXt
+	// chunk, which holds Latin-1.
+	UTF8 bool
+}
+
+// Writer passes a PNG through, adding text chunks right after the header
+type Writer struct {
+	w      io.Writer
+	texts  []Text
+	header []byte
+	done   bool
+}
+
+// NewWriter returns a writer that adds texts to the PNG written through it.
+// Keywords are 1 to 79 Latin-1 characters.
+func NewWriter(w io.Writer, texts ...Text) *Writer {
+	return &Writer{w: w, texts: texts}
+}
+
+func (tw *Writer) Write(b []byte) (int, error) {
+	if tw.done {
+		return tw.w.Write(b)
+	}
+	n := min(len(b), headerSize-len(tw.header))
+	tw.header = append(tw.header, b[:n]...)
+	if len(tw.header) < headerSize {
+		return len(b), nil
+	}
+	if string(tw.header[:len(signature)]) != signature || string(tw.header[len(signature)+4:len(signature)+8]) != "IHDR" {
+		return 0, errors.New("PNG doesn't start with a header")
+	}
+	if _, err := tw.w.Write(tw.header); err != nil {
+		return 0, fmt.Errorf("failed to write PNG header: %w", err)
+	}
+	for _, t := range tw.texts {
+		kind, data, err := t.encode()
+		if err != nil {
+			return 0, err
+		}
+		if err := WriteChunk(tw.w, kind, data); err != nil {
+			return 0, err
+		}
+	}
+	tw.done = true
+	if _, err := tw.w.Write(b[n:]); err != nil {
+		return n, err
+	}
+	return len(b), nil
+}
+
+// encode returns the chunk type and data of a text
+func (t Text) encode() (string, []byte, error) {
+	keyword, ok := latin1(t.Keyword)
+	if !ok || len(keyword) < 1 || len(keyword) > 79 || bytes.IndexByte(keyword, 0) >= 0 {
+		return "", nil, fmt.Errorf("invalid PNG text keyword %q", t.Keyword)
+	}
+	data := append(keyword, 0)
+	if !t.UTF8 {
+		value, ok := latin1(t.Value)
+		if !ok {
+			return "", nil, fmt.Errorf("PNG text %q isn't Latin-1", t.Keyword)
+		}
+		return "tEXt", append(data, value...), nil
+	}
+	// Uncompressed, with no language tag or translated keyword
+	data = append(data, 0, 0, 0, 0)
+	return "iTXt", append(data, t.Value...), nil
+}
+
+// Read returns the texts of a PNG, from tEXt, zTXt and iTXt chunks
+func Read(r io.Reader) ([]Text, error) {
+	sig := make([]byte, len(signature))
+	if _, err := io.ReadFull(r, sig); err != nil || string(sig) != signature {
+		return nil, errors.New("not a PNG")
+	}
+	var texts []Text
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, fmt.Errorf("failed to read PNG chunk: %w", err)
+		}
+		length := binary.BigEndian.Uint32(header)
+		kind := string(header[4:])
+		switch kind {
+		case "tEXt", "zTXt", "iTXt":
+			if length > maxTextSize {
+				return nil, fmt.Errorf("PNG %s chunk of %d bytes is too large", kind, length)
+			}
+			data := make([]byte, length+4)
+			if _, err := io.ReadFull(r, data); err != nil {
+				return nil, fmt.Errorf("failed to read PNG %s chunk: %w", kind, err)
+			}
+			crc := crc32.NewIEEE()
+			crc.Write(header[4:])
+			crc.Write(data[:length])
+			if crc.Sum32() != binary.BigEndian.Uint32(data[length:]) {
+				return nil, fmt.Errorf("PNG %s chunk is corrupt", kind)
+			}
+			text, err := decode(kind, data[:length])
+			if err != nil {
+				return nil, err
+			}
+			texts = append(texts, text)
+		case "IEND":
+			return texts, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+				return nil, fmt.Errorf("failed to read PNG %s chunk: %w", kind, err)
+			}
+		}
+	}
+}
+
+// Lookup returns the value of the first text with a keyword
+func Lookup(texts []Text, keyword string) (string, bool) {
+	for _, t := range texts {
+		if t.Keyword == keyword {
+			return t.Value, true
+		}
+	}
+	return "", false
+}
+
+// decode parses the data of a text chunk
+func decode(kind string, data []byte) (Text, error) {
+	keyword, rest, ok := bytes.Cut(data, []byte{0})
+	if !ok {
+		return Text{}, fmt.Errorf("PNG %s chunk has no keyword", kind)
+	}
+	text := Text{Keyword: fromLatin1(keyword)}
+	switch kind {
+	case "tEXt":
+		text.Value = fromLatin1(rest)
+		return text, nil
+	case "zTXt":
+		if len(rest) < 1 {
+			return Text{}, errors.New("PNG zTXt chunk is truncated")
+		}
+		value, err := inflate(rest[1:])
+		if err != nil {
+			return Text{}, err
+		}
+		text.Value = fromLatin1(value)
+		return text, nil
+	}
+
+	if len(rest) < 2 {
+		return Text{}, errors.New("PNG iTXt chunk is truncated")
+	}
+	compressed := rest[0] == 1
+	// Skip the compression method, language tag and translated keyword
+	rest = rest[2:]
+	for range 2 {
+		if _, rest, ok = bytes.Cut(rest, []byte{0}); !ok {
+			return Text{}, errors.New("PNG iTXt chunk is truncated")
+		}
+	}
+	if compressed {
+		var err error
+		if rest, err = inflate(rest); err != nil {
+			return Text{}, err
+		}
+	}
+	if !utf8.Valid(rest) {
+		return Text{}, fmt.Errorf("PNG iTXt %q isn't UTF-8", text.Keyword)
+	}
+	text.Value = string(rest)
+	text.UTF8 = true
+	return text, nil
+}
+
+func inflate(data []byte) ([]byte, error) {
+	z, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress PNG text: %w", err)
+	}
+	defer z.Close()
+	value, err := io.ReadAll(io.LimitReader(z, maxTextSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress PNG text: %w", err)
+	}
+	return value, nil
+}
+
+// latin1 converts a string to Latin-1, failing on characters it lacks
+func latin1(s string) ([]byte, bool) {
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, false
+		}
+		b = append(b, byte(r))
+	}
+	return b, true
+}
+
+func fromLatin1(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		sb.WriteRune(rune(c))
+	}
+	return sb.String()
+}
+
+// WriteChunk writes a PNG chunk of a four letter type, with its length and
+// CRC
+func WriteChunk(w io.Writer, kind string, data []byte) error {
+	if len(kind) != 4 {
+		return errors.New("PNG chunk types have 4 letters")
+	}
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	footer := binary.BigEndian.AppendUint32(nil, crc.Sum32())
+	for _, b := range [][]byte{header, data, footer} {
+		if _, err := w.Write(b); err != nil {
+			return fmt.Errorf("failed to write %s chunk: %w", kind, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package pngtext
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	var original bytes.Buffer
+	if err := png.Encode(&original, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	texts := []Text{
+		{Keyword: "Software", Value: "mandelbrot dev"},
+		{Keyword: "Comment", Value: "Latin-1 café ±½"},
+		{Keyword: "mandelbrot.view", Value: "center: (-0.75+0.1i)\nnote: ☃ 日本\n", UTF8: true},
+		{Keyword: "Empty", Value: ""},
+	}
+
+	// Written a byte at a time, the header arrives split across writes
+	for _, tt := range []struct {
+		name      string
+		texts     []Text
+		chunkSize int
+		ok        bool
+	}{
+		{name: "whole", texts: texts, chunkSize: original.Len(), ok: true},
+		{name: "bytes", texts: texts, chunkSize: 1, ok: true},
+		{name: "chunks", texts: texts, chunkSize: 7, ok: true},
+		{name: "no texts", chunkSize: original.Len(), ok: true},
+		{name: "no keyword", texts: []Text{{Value: "value"}}, chunkSize: original.Len()},
+		{name: "long keyword", texts: []Text{{Keyword: strings.Repeat("k", 80)}}, chunkSize: original.Len()},
+		{name: "keyword isn't Latin-1", texts: []Text{{Keyword: "☃"}}, chunkSize: original.Len()},
+		{name: "value isn't Latin-1", texts: []Text{{Keyword: "Comment", Value: "☃"}}, chunkSize: original.Len()},
+	} {
+		var buf bytes.Buffer
+		w := NewWriter(&buf, tt.texts...)
+		var err error
+		for b := original.Bytes(); len(b) > 0 && err == nil; b = b[min(tt.chunkSize, len(b)):] {
+			var n int
+			if n, err = w.Write(b[:min(tt.chunkSize, len(b))]); err == nil && n != min(tt.chunkSize, len(b)) {
+				t.Fatalf("%s: wrote %d of %d bytes", tt.name, n, min(tt.chunkSize, len(b)))
+			}
+		}
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Write returned %v, want ok %t", tt.name, err, tt.ok)
+			continue
+		}
+		if !tt.ok {
+			continue
+		}
+
+		got, err := Read(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(got) != len(tt.texts) {
+			t.Fatalf("%s: read %d texts, want %d", tt.name, len(got), len(tt.texts))
+		}
+		for i := range got {
+			if got[i] != tt.texts[i] {
+				t.Errorf("%s: text %d is %+v, want %+v", tt.name, i, got[i], tt.texts[i])
+			}
+		}
+		// The texts follow the header and the image is unchanged
+		if !bytes.HasPrefix(buf.Bytes(), original.Bytes()[:headerSize]) || !bytes.HasSuffix(buf.Bytes(), original.Bytes()[headerSize:]) {
+			t.Errorf("%s: texts aren't inserted right after the header", tt.name)
+		}
+		if _, err := png.Decode(&buf); err != nil {
+			t.Errorf("%s: PNG with texts doesn't decode: %v", tt.name, err)
+		}
+	}
+
+	if _, err := NewWriter(&bytes.Buffer{}).Write([]byte(strings.Repeat("x", headerSize))); err == nil {
+		t.Error("a file that isn't a PNG was written")
+	}
+}
+
+func TestRead(t *testing.T) {
+	var original bytes.Buffer
+	if err := png.Encode(&original, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	compress := func(s string) []byte {
+		var buf bytes.Buffer
+		z := zlib.NewWriter(&buf)
+		z.Write([]byte(s))
+		z.Close()
+		return buf.Bytes()
+	}
+	withChunks := func(chunks ...[2]string) []byte {
+		var buf bytes.Buffer
+		buf.Write(original.Bytes()[:headerSize])
+		for _, c := range chunks {
+			WriteChunk(&buf, c[0], []byte(c[1]))
+		}
+		buf.Write(original.Bytes()[headerSize:])
+		return buf.Bytes()
+	}
+	corrupt := withChunks([2]string{"tEXt", "Comment\x00hello"})
+	corrupt[bytes.Index(corrupt, []byte("hello"))] = 'j'
+
+	for _, tt := range []struct {
+		name string
+		file []byte
+		want []Text
+		ok   bool
+	}{
+		{name: "none", file: original.Bytes(), ok: true},
+		{
+			name: "tEXt",
+			file: withChunks([2]string{"tEXt", "Title\x00caf\xe9"}),
+			want: []Text{{Keyword: "Title", Value: "café"}},
+			ok:   true,
+		},
+		{
+			name: "zTXt",
+			file: withChunks([2]string{"zTXt", "Title\x00\x00" + string(compress("caf\xe9"))}),
+			want: []Text{{Keyword: "Title", Value: "café"}},
+			ok:   true,
+		},
+		{
+			name: "iTXt",
+			file: withChunks([2]string{"iTXt", "Description\x00\x00\x00en\x00Beschreibung\x00☃"}),
+			want: []Text{{Keyword: "Description", Value: "☃", UTF8: true}},
+			ok:   true,
+		},
+		{
+			name: "compressed iTXt",
+			file: withChunks([2]string{"iTXt", "Description\x00\x01\x00en\x00Beschreibung\x00" + string(compress("☃"))}),
+			want: []Text{{Keyword: "Description", Value: "☃", UTF8: true}},
+			ok:   true,
+		},
+		{name: "corrupt", file: corrupt},
+		{name: "not a PNG", file: []byte("GIF89a")},
+	} {
+		got, err := Read(bytes.NewReader(tt.file))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: Read returned %v, want ok %t", tt.name, err, tt.ok)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: read %+v, want %+v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: text %d is %+v, want %+v", tt.name, i, got[i], tt.want[i])
+			}
+			if value, ok := Lookup(got, tt.want[i].Keyword); !ok || value != tt.want[i].Value {
+				t.Errorf("%s: Lookup(%q) returned %q, %t", tt.name, tt.want[i].Keyword, value, ok)
+			}
+		}
+		if _, ok := Lookup(got, "Missing"); ok {
+			t.Errorf("%s: Lookup found a missing keyword", tt.name)
+		}
+	}
+}
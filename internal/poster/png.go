@@ -0,0 +1,177 @@
+package poster
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/pngtext"
+)
+
+// idatSize is how much compressed data is gathered into each IDAT chunk
+const idatSize = 1 << 16
+
+// PNGWriter streams an 8 bit RGB PNG, compressing rows as they arrive
+type PNGWriter struct {
+	w             *bufio.Writer
+	idat          *chunkWriter
+	z             *zlib.Writer
+	width, height int
+	rows          int
+	// prev and cur are the unfiltered previous and current rows, filtered
+	// holds the candidates for each filter type after its type byte
+	prev, cur []byte
+	filtered  [5][]byte
+}
+
+// NewPNGWriter writes the PNG header for an image of the given size
+func NewPNGWriter(w io.Writer, width, height int) (*PNGWriter, error) {
+	if width < 1 || height < 1 || width > 1<<31-1 || height > 1<<31-1 {
+		return nil, fmt.Errorf("invalid PNG size %dx%d", width, height)
+	}
+	pw := &PNGWriter{
+		w:      bufio.NewWriter(w),
+		width:  width,
+		height: height,
+		prev:   make([]byte, 3*width),
+		cur:    make([]byte, 3*width),
+	}
+	for i := range pw.filtered {
+		pw.filtered[i] = make([]byte, 1+3*width)
+		pw.filtered[i][0] = byte(i)
+	}
+	pw.idat = &chunkWriter{w: pw.w}
+	pw.z = zlib.NewWriter(pw.idat)
+
+	if _, err := pw.w.WriteString("\x89PNG\r\n\x1a\n"); err != nil {
+		return nil, fmt.Errorf("failed to write PNG signature: %w", err)
+	}
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = 2 // truecolor
+	if err := pngtext.WriteChunk(pw.w, "IHDR", ihdr); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+func (pw *PNGWriter) WriteStrip(strip *image.RGBA) error {
+	if strip.Rect.Dx() != pw.width || strip.Rect.Min.Y != pw.rows {
+		return fmt.Errorf("strip %v doesn't continue the image at row %d", strip.Rect, pw.rows)
+	}
+	for y := strip.Rect.Min.Y; y < strip.Rect.Max.Y; y++ {
+		rgb(pw.cur, strip, y)
+		if _, err := pw.z.Write(pw.filter()); err != nil {
+			return fmt.Errorf("failed to compress row %d: %w", y, err)
+		}
+		pw.prev, pw.cur = pw.cur, pw.prev
+		pw.rows++
+	}
+	return nil
+}
+
+func (pw *PNGWriter) Close() error {
+	if pw.rows != pw.height {
+		return fmt.Errorf("PNG has %d of %d rows", pw.rows, pw.height)
+	}
+	if err := pw.z.Close(); err != nil {
+		return fmt.Errorf("failed to finish compressing: %w", err)
+	}
+	if err := pw.idat.Flush(); err != nil {
+		return err
+	}
+	if err := pngtext.WriteChunk(pw.w, "IEND", nil); err != nil {
+		return err
+	}
+	if err := pw.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush PNG: %w", err)
+	}
+	return nil
+}
+
+// filter applies every filter type to the current row and returns the one
+// with the smallest sum of absolute differences, the heuristic the PNG
+// specification suggests
+func (pw *PNGWriter) filter() []byte {
+	const bpp = 3
+	// The first row has no row above, which the zeroed prev stands in for
+	cur, prev := pw.cur, pw.prev
+	none, sub, up, avg, paeth := pw.filtered[0][1:], pw.filtered[1][1:], pw.filtered[2][1:], pw.filtered[3][1:], pw.filtered[4][1:]
+	for i := range cur {
+		var left, upLeft byte
+		if i >= bpp {
+			left, upLeft = cur[i-bpp], prev[i-bpp]
+		}
+		none[i] = cur[i]
+		sub[i] = cur[i] - left
+		up[i] = cur[i] - prev[i]
+		avg[i] = cur[i] - byte((int(left)+int(prev[i]))/2)
+		paeth[i] = cur[i] - paethPredictor(left, prev[i], upLeft)
+	}
+	best, bestSum := 0, -1
+	for i, f := range pw.filtered {
+		sum := 0
+		for _, b := range f[1:] {
+			sum += abs(int(int8(b)))
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = i, sum
+		}
+	}
+	return pw.filtered[best]
+}
+
+func paethPredictor(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	default:
+		return c
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// chunkWriter gathers what is written to it into IDAT chunks
+type chunkWriter struct {
+	w   io.Writer
+	buf []byte
+}
+
+func (c *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		take := min(len(p), idatSize-len(c.buf))
+		c.buf = append(c.buf, p[:take]...)
+		p = p[take:]
+		if len(c.buf) == idatSize {
+			if err := c.Flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (c *chunkWriter) Flush() error {
+	if len(c.buf) == 0 {
+		return nil
+	}
+	err := pngtext.WriteChunk(c.w, "IDAT", c.buf)
+	c.buf = c.buf[:0]
+	return err
+}
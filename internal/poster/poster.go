@@ -0,0 +1,73 @@
+package poster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+// DefaultStripHeight keeps a strip of a 30000 pixel wide poster under 8 MiB
+const DefaultStripHeight = 64
+
+// StripWriter encodes an image handed to it in strips, from the top down.
+// Each strip covers the full width and shares the coordinate space of the
+// whole image.
+type StripWriter interface {
+	WriteStrip(strip *image.RGBA) error
+	// Close finishes the file, failing if fewer rows than the image has
+	// were written
+	Close() error
+}
+
+type Options struct {
+	// StripHeight is how many rows are rendered and held at a time
+	StripHeight int
+	// Progress is called after each strip with the rows written so far
+	Progress func(rows, total int)
+}
+
+// Render renders the view strip by strip into w, so memory stays bounded
+// however large the image is. It stops between strips once ctx is done,
+// leaving w unfinished.
+func Render(ctx context.Context, p mandelbrot.RenderParams, w StripWriter, opts Options) error {
+	if p.UsesInverseIteration() {
+		return errors.New("inverse iteration needs the whole image at once, use escape time for posters")
+	}
+	if opts.StripHeight < 1 {
+		return fmt.Errorf("invalid strip height %d", opts.StripHeight)
+	}
+
+	// Strips reuse one buffer, moving its bounds down the image
+	buffer := make([]byte, 4*p.Width*min(opts.StripHeight, p.Height))
+	for y := 0; y < p.Height; y += opts.StripHeight {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("stopped at row %d: %w", y, err)
+		}
+		bounds := image.Rect(0, y, p.Width, min(y+opts.StripHeight, p.Height))
+		strip := &image.RGBA{
+			Pix:    buffer[:4*bounds.Dx()*bounds.Dy()],
+			Stride: 4 * bounds.Dx(),
+			Rect:   bounds,
+		}
+		mandelbrot.RenderInto(p, strip, nil)
+		if err := w.WriteStrip(strip); err != nil {
+			return fmt.Errorf("failed to write rows %d to %d: %w", bounds.Min.Y, bounds.Max.Y, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(bounds.Max.Y, p.Height)
+		}
+	}
+	return nil
+}
+
+// rgb packs a row of an RGBA strip into 8 bit RGB. Renders are opaque, so
+// alpha is dropped.
+func rgb(dst []byte, strip *image.RGBA, y int) {
+	src := strip.Pix[strip.PixOffset(strip.Rect.Min.X, y):]
+	for x := range strip.Rect.Dx() {
+		copy(dst[3*x:3*x+3], src[4*x:4*x+3])
+	}
+}
@@ -0,0 +1,105 @@
+package poster
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+	"golang.org/x/image/tiff"
+)
+
+func TestStripWriters(t *testing.T) {
+	// Random rows exercise every PNG filter and spread the PNG over several
+	// IDAT chunks and the TIFF over several strips, the smooth rows let the
+	// filters other than none win
+	noise := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	rand.New(rand.NewSource(1)).Read(noise.Pix)
+	for y := range noise.Rect.Dy() {
+		for x := range noise.Rect.Dx() {
+			pix := noise.Pix[noise.PixOffset(x, y):]
+			if y < noise.Rect.Dy()/2 {
+				pix[0], pix[1], pix[2] = byte(x), byte(y), byte(x+y)
+			}
+			pix[3] = 255
+		}
+	}
+	params := mandelbrot.DefaultRenderParams(97, 53)
+	render := mandelbrot.Render(params)
+
+	for _, tt := range []struct {
+		name   string
+		writer func(w io.Writer, width, height int) (StripWriter, error)
+		decode func(r io.Reader) (image.Image, error)
+	}{
+		{
+			name:   "png",
+			writer: func(w io.Writer, width, height int) (StripWriter, error) { return NewPNGWriter(w, width, height) },
+			decode: png.Decode,
+		},
+		{
+			name:   "tiff",
+			writer: func(w io.Writer, width, height int) (StripWriter, error) { return NewTIFFWriter(w, width, height) },
+			decode: tiff.Decode,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := tt.writer(&buf, noise.Rect.Dx(), noise.Rect.Dy())
+			if err != nil {
+				t.Fatal(err)
+			}
+			for y := 0; y < noise.Rect.Dy(); y += 17 {
+				strip := noise.SubImage(image.Rect(0, y, noise.Rect.Dx(), min(y+17, noise.Rect.Dy()))).(*image.RGBA)
+				if err := w.WriteStrip(strip); err != nil {
+					t.Fatalf("failed to write row %d: %v", y, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if img, err := tt.decode(&buf); err != nil {
+				t.Errorf("failed to decode: %v", err)
+			} else if rgba, ok := img.(*image.RGBA); !ok || !bytes.Equal(rgba.Pix, noise.Pix) {
+				t.Error("decoded image differs from the strips written")
+			}
+
+			// Rendering in strips matches rendering the whole image at once
+			buf.Reset()
+			w, err = tt.writer(&buf, params.Width, params.Height)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Render(context.Background(), params, w, Options{StripHeight: 10}); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if img, err := tt.decode(&buf); err != nil {
+				t.Errorf("failed to decode the render: %v", err)
+			} else if rgba, ok := img.(*image.RGBA); !ok || !bytes.Equal(rgba.Pix, render.Pix) {
+				t.Error("render written in strips differs from the whole render")
+			}
+
+			// Strips have to continue the image and cover all of it
+			w, err = tt.writer(io.Discard, 8, 8)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.WriteStrip(noise.SubImage(image.Rect(0, 2, 8, 4)).(*image.RGBA)); err == nil {
+				t.Error("a strip skipping rows was accepted")
+			}
+			if err := w.WriteStrip(image.NewRGBA(image.Rect(0, 0, 8, 4))); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err == nil {
+				t.Error("closing with rows missing succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package poster
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// tiffStripSize is roughly how many bytes each TIFF strip holds, as readers
+// prefer strips of a few kilobytes
+const tiffStripSize = 64 << 10
+
+// TIFF field types
+const (
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// TIFFWriter streams an uncompressed 8 bit RGB baseline TIFF. Without
+// compression every strip's offset is known up front, so the directory is
+// written first and the rows follow as they arrive.
+type TIFFWriter struct {
+	w             *bufio.Writer
+	width, height int
+	rows          int
+	row           []byte
+}
+
+// tiffEntry is a field of the image file directory
+type tiffEntry struct {
+	tag, kind uint16
+	values    []uint32
+}
+
+// NewTIFFWriter writes the TIFF header and directory for an image of the
+// given size. Classic TIFF addresses at most 4 GiB.
+func NewTIFFWriter(w io.Writer, width, height int) (*TIFFWriter, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("invalid TIFF size %dx%d", width, height)
+	}
+	rowSize := 3 * width
+	rowsPerStrip := max(1, tiffStripSize/rowSize)
+	strips := (height + rowsPerStrip - 1) / rowsPerStrip
+
+	entries := []tiffEntry{
+		{256, tiffLong, []uint32{uint32(width)}},
+		{257, tiffLong, []uint32{uint32(height)}},
+		{258, tiffShort, []uint32{8, 8, 8}}, // bits per sample
+		{259, tiffShort, []uint32{1}},       // no compression
+		{262, tiffShort, []uint32{2}},       // RGB
+		{273, tiffLong, make([]uint32, strips)},
+		{277, tiffShort, []uint32{3}}, // samples per pixel
+		{278, tiffLong, []uint32{uint32(rowsPerStrip)}},
+		{279, tiffLong, make([]uint32, strips)},
+		{282, tiffRational, []uint32{72, 1}},
+		{283, tiffRational, []uint32{72, 1}},
+		{296, tiffShort, []uint32{2}}, // resolution in inches
+	}
+
+	// Values that don't fit in an entry follow the directory, then the rows
+	ifdSize := 2 + 12*len(entries) + 4
+	extra := 0
+	for _, e := range entries {
+		if size := e.size(); size > 4 {
+			extra += size + size%2
+		}
+	}
+	dataOffset := uint64(8 + ifdSize + extra)
+	if dataOffset+uint64(rowSize)*uint64(height) > math.MaxUint32 {
+		return nil, fmt.Errorf("a %dx%d TIFF is larger than the 4 GiB TIFF can address, use PNG", width, height)
+	}
+	offsets, counts := entries[5].values, entries[8].values
+	for i := range strips {
+		rows := min(rowsPerStrip, height-i*rowsPerStrip)
+		offsets[i] = uint32(dataOffset) + uint32(i*rowsPerStrip*rowSize)
+		counts[i] = uint32(rows * rowSize)
+	}
+
+	tw := &TIFFWriter{
+		w:      bufio.NewWriter(w),
+		width:  width,
+		height: height,
+		row:    make([]byte, rowSize),
+	}
+	header := []byte{'I', 'I', 42, 0}
+	header = binary.LittleEndian.AppendUint32(header, 8)
+	ifd := binary.LittleEndian.AppendUint16(nil, uint16(len(entries)))
+	var values []byte
+	next := uint32(8 + ifdSize)
+	for _, e := range entries {
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.tag)
+		ifd = binary.LittleEndian.AppendUint16(ifd, e.kind)
+		ifd = binary.LittleEndian.AppendUint32(ifd, e.count())
+		data := e.bytes()
+		if len(data) <= 4 {
+			ifd = append(ifd, data...)
+			ifd = append(ifd, make([]byte, 4-len(data))...)
+			continue
+		}
+		ifd = binary.LittleEndian.AppendUint32(ifd, next)
+		// Values start on word boundaries
+		data = append(data, make([]byte, len(data)%2)...)
+		values = append(values, data...)
+		next += uint32(len(data))
+	}
+	ifd = binary.LittleEndian.AppendUint32(ifd, 0) // no further directories
+	for _, b := range [][]byte{header, ifd, values} {
+		if _, err := tw.w.Write(b); err != nil {
+			return nil, fmt.Errorf("failed to write TIFF header: %w", err)
+		}
+	}
+	return tw, nil
+}
+
+func (tw *TIFFWriter) WriteStrip(strip *image.RGBA) error {
+	if strip.Rect.Dx() != tw.width || strip.Rect.Min.Y != tw.rows {
+		return fmt.Errorf("strip %v doesn't continue the image at row %d", strip.Rect, tw.rows)
+	}
+	for y := strip.Rect.Min.Y; y < strip.Rect.Max.Y; y++ {
+		rgb(tw.row, strip, y)
+		if _, err := tw.w.Write(tw.row); err != nil {
+			return fmt.Errorf("failed to write row %d: %w", y, err)
+		}
+		tw.rows++
+	}
+	return nil
+}
+
+func (tw *TIFFWriter) Close() error {
+	if tw.rows != tw.height {
+		return fmt.Errorf("TIFF has %d of %d rows", tw.rows, tw.height)
+	}
+	if err := tw.w.Flush(); err != nil {
+		return fmt.Errorf("failed to flush TIFF: %w", err)
+	}
+	return nil
+}
+
+// count is the number of values of the entry's type, rationals taking two
+func (e tiffEntry) count() uint32 {
+	if e.kind == tiffRational {
+		return uint32(len(e.values) / 2)
+	}
+	return uint32(len(e.values))
+}
+
+func (e tiffEntry) size() int {
+	if e.kind == tiffShort {
+		return 2 * len(e.values)
+	}
+	return 4 * len(e.values)
+}
+
+func (e tiffEntry) bytes() []byte {
+	var b []byte
+	for _, v := range e.values {
+		if e.kind == tiffShort {
+			b = binary.LittleEndian.AppendUint16(b, uint16(v))
+		} else {
+			b = binary.LittleEndian.AppendUint32(b, v)
+		}
+	}
+	return b
+}
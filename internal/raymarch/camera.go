@@ -0,0 +1,64 @@
+package raymarch
+
+import "math"
+
+// maxPitch keeps the camera from flipping over the poles
+const maxPitch = 89
+
+// Camera orbits a target, looking at it from Distance away
+type Camera struct {
+	Target Vec3
+	// Yaw turns the camera around the vertical axis and Pitch raises it
+	// above the horizontal plane, both in degrees
+	Yaw, Pitch float64
+	Distance   float64
+	// FOV is the vertical field of view in degrees
+	FOV float64
+}
+
+func DefaultCamera() Camera {
+	return Camera{
+		Yaw:      30,
+		Pitch:    20,
+		Distance: 3,
+		FOV:      45,
+	}
+}
+
+// Orbit turns the camera around its target by the given degrees
+func (c *Camera) Orbit(yaw, pitch float64) {
+	c.Yaw = math.Mod(c.Yaw+yaw, 360)
+	c.Pitch = min(maxPitch, max(-maxPitch, c.Pitch+pitch))
+}
+
+// Position is where the camera sits in space
+func (c *Camera) Position() Vec3 {
+	yaw := c.Yaw * math.Pi / 180
+	pitch := c.Pitch * math.Pi / 180
+	offset := Vec3{
+		X: math.Cos(pitch) * math.Cos(yaw),
+		Y: math.Sin(pitch),
+		Z: math.Cos(pitch) * math.Sin(yaw),
+	}
+	return c.Target.Add(offset.Scale(c.Distance))
+}
+
+// Ray returns the origin and direction of the ray through a point of an
+// image, in pixels from its top left
+func (c *Camera) Ray(x, y float64, width, height int) (origin, direction Vec3) {
+	origin = c.Position()
+	forward := c.Target.Sub(origin).Normalize()
+	right := forward.Cross(Vec3{Y: 1}).Normalize()
+	up := right.Cross(forward)
+
+	half := math.Tan(c.FOV * math.Pi / 360)
+	u := (2*x/float64(width) - 1) * half * float64(width) / float64(height)
+	v := (1 - 2*y/float64(height)) * half
+	direction = forward.Add(right.Scale(u)).Add(up.Scale(v)).Normalize()
+	return origin, direction
+}
+
+// PixelAngle is the angle a pixel covers at the center of the image
+func (c *Camera) PixelAngle(height int) float64 {
+	return 2 * math.Tan(c.FOV*math.Pi/360) / float64(height)
+}
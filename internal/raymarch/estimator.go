@@ -0,0 +1,62 @@
+package raymarch
+
+import "math"
+
+// Estimator is a 3D fractal that can be ray marched. Estimate returns a
+// distance that never overshoots the surface, and an orbit trap between 0
+// and 1 used to color it.
+type Estimator interface {
+	Estimate(p Vec3) (distance, trap float64)
+	// Radius bounds the fractal in a sphere around the origin
+	Radius() float64
+}
+
+// Mandelbulb is the spherical coordinate power-n analogue of the
+// Mandelbrot set
+type Mandelbulb struct {
+	Power      float64
+	Iterations int
+	Bailout    float64
+}
+
+func NewMandelbulb(power float64) *Mandelbulb {
+	return &Mandelbulb{
+		Power:      power,
+		Iterations: 12,
+		Bailout:    2,
+	}
+}
+
+func (m *Mandelbulb) Radius() float64 {
+	// The bulb stays within the bailout sphere for any power
+	return m.Bailout
+}
+
+func (m *Mandelbulb) Estimate(p Vec3) (float64, float64) {
+	z := p
+	dr := 1.0
+	r := z.Length()
+	trap := math.Inf(1)
+	for range m.Iterations {
+		if r > m.Bailout || r == 0 {
+			break
+		}
+		theta := math.Acos(min(1, max(-1, z.Z/r))) * m.Power
+		phi := math.Atan2(z.Y, z.X) * m.Power
+		rn := math.Pow(r, m.Power-1)
+		dr = rn*m.Power*dr + 1
+		rn *= r
+		sinTheta := math.Sin(theta)
+		z = Vec3{
+			X: rn * sinTheta * math.Cos(phi),
+			Y: rn * sinTheta * math.Sin(phi),
+			Z: rn * math.Cos(theta),
+		}.Add(p)
+		r = z.Length()
+		trap = min(trap, r)
+	}
+	if r == 0 {
+		return 0, 0
+	}
+	return 0.5 * math.Log(r) * r / dr, min(1, trap)
+}
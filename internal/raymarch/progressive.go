@@ -0,0 +1,66 @@
+package raymarch
+
+import (
+	"context"
+	"image"
+	"sync"
+)
+
+//nolint:golint,gochecknoglobals
+var progressiveBlocks = []int{16, 4, 1}
+
+// Progressive renders in the background, starting coarse and refining, so
+// an interactive view can show something right away. The latest finished
+// pass is available from Image at any time.
+type Progressive struct {
+	mu     sync.Mutex
+	image  *image.RGBA
+	pass   int
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// Start abandons any render in progress and starts rendering p
+func (r *Progressive) Start(p Params) {
+	r.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for _, block := range progressiveBlocks {
+			img := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+			if err := RenderInto(ctx, p, img, block); err != nil {
+				return
+			}
+			r.mu.Lock()
+			r.image = img
+			r.pass++
+			r.mu.Unlock()
+		}
+	}()
+}
+
+// Stop abandons the render in progress and waits for it to wind down
+func (r *Progressive) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	r.wg.Wait()
+}
+
+// Image returns the latest finished pass, nil before the first one, and a
+// counter that changes whenever a new pass finishes
+func (r *Progressive) Image() (*image.RGBA, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.image, r.pass
+}
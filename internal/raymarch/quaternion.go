@@ -0,0 +1,97 @@
+package raymarch
+
+import "math"
+
+// Quaternion is r + i·I + j·J + k·K
+type Quaternion struct {
+	R, I, J, K float64
+}
+
+func (a Quaternion) Add(b Quaternion) Quaternion {
+	return Quaternion{a.R + b.R, a.I + b.I, a.J + b.J, a.K + b.K}
+}
+
+func (a Quaternion) Mul(b Quaternion) Quaternion {
+	return Quaternion{
+		R: a.R*b.R - a.I*b.I - a.J*b.J - a.K*b.K,
+		I: a.R*b.I + a.I*b.R + a.J*b.K - a.K*b.J,
+		J: a.R*b.J - a.I*b.K + a.J*b.R + a.K*b.I,
+		K: a.R*b.K + a.I*b.J - a.J*b.I + a.K*b.R,
+	}
+}
+
+func (a Quaternion) Norm() float64 {
+	return math.Sqrt(a.R*a.R + a.I*a.I + a.J*a.J + a.K*a.K)
+}
+
+// Slice picks the 3D hyperplane of quaternion space that gets rendered.
+// Unrotated, x, y and z map to r, i and j, and k is fixed at W.
+type Slice struct {
+	W float64
+	// XW, YW and ZW rotate the hyperplane towards the k axis, in degrees
+	XW, YW, ZW float64
+}
+
+// Point maps a point of the rendered space into quaternion space
+func (s *Slice) Point(p Vec3) Quaternion {
+	q := [4]float64{p.X, p.Y, p.Z, s.W}
+	for axis, degrees := range [3]float64{s.XW, s.YW, s.ZW} {
+		if degrees == 0 {
+			continue
+		}
+		sin, cos := math.Sincos(degrees * math.Pi / 180)
+		q[axis], q[3] = q[axis]*cos-q[3]*sin, q[axis]*sin+q[3]*cos
+	}
+	return Quaternion{q[0], q[1], q[2], q[3]}
+}
+
+// Rotate turns the slice by the given degrees in each plane
+func (s *Slice) Rotate(xw, yw, zw float64) {
+	s.XW = math.Mod(s.XW+xw, 360)
+	s.YW = math.Mod(s.YW+yw, 360)
+	s.ZW = math.Mod(s.ZW+zw, 360)
+}
+
+// QuaternionJulia is the filled Julia set of q² + C in quaternion space,
+// seen through a 3D slice
+type QuaternionJulia struct {
+	C          Quaternion
+	Slice      Slice
+	Iterations int
+	Bailout    float64
+}
+
+func NewQuaternionJulia(c Quaternion) *QuaternionJulia {
+	return &QuaternionJulia{
+		C:          c,
+		Iterations: 16,
+		Bailout:    4,
+	}
+}
+
+func (j *QuaternionJulia) Radius() float64 {
+	// Orbits starting outside the larger root of |q|² = |q| + |C| escape
+	return (1 + math.Sqrt(1+4*j.C.Norm())) / 2
+}
+
+func (j *QuaternionJulia) Estimate(p Vec3) (float64, float64) {
+	q := j.Slice.Point(p)
+	// Only the length of the derivative matters, and |2·q·dq| = 2|q||dq|
+	dq := 1.0
+	r := q.Norm()
+	trap := math.Inf(1)
+	for range j.Iterations {
+		if r > j.Bailout {
+			break
+		}
+		dq *= 2 * r
+		q = q.Mul(q).Add(j.C)
+		r = q.Norm()
+		trap = min(trap, r)
+	}
+	if r <= j.Bailout || dq == 0 {
+		// Still bounded, so as far as this estimate can tell it's inside
+		return 0, min(1, trap)
+	}
+	return 0.5 * r * math.Log(r) / dq, min(1, trap)
+}
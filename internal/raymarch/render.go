@@ -0,0 +1,222 @@
+package raymarch
+
+import (
+	"context"
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/USA-RedDragon/mandelbrot/internal/mandelbrot"
+)
+
+const (
+	// shadowSharpness is how quickly soft shadows fall off near occluders
+	shadowSharpness = 8
+	shadowSteps     = 64
+	// occlusionSamples are taken along the normal to darken creases
+	occlusionSamples = 5
+	occlusionStep    = 0.03
+	// specularShininess is the Phong exponent of the highlight
+	specularShininess = 32
+)
+
+// Params fully describes a ray marched image, like mandelbrot.RenderParams
+// does for the plane
+type Params struct {
+	Estimator     Estimator
+	Camera        Camera
+	Width, Height int
+	Palette       *mandelbrot.Palette
+	// Light points towards where the light comes from
+	Light            Vec3
+	Ambient          float64
+	Specular         float64
+	Shadows          bool
+	AmbientOcclusion bool
+	// MaxSteps bounds the number of steps along each ray
+	MaxSteps int
+	// Detail scales the distance at which a ray counts as hitting the
+	// surface, 1 being a pixel
+	Detail float64
+	// Background is the color of rays that miss
+	Background [4]byte
+}
+
+func DefaultParams(width, height int) Params {
+	return Params{
+		Estimator:        NewMandelbulb(8),
+		Camera:           DefaultCamera(),
+		Width:            width,
+		Height:           height,
+		Palette:          mandelbrot.NewPalette(mandelbrot.PaletteModeSimpleRainbow),
+		Light:            Vec3{X: 0.6, Y: 0.8, Z: 0.3}.Normalize(),
+		Ambient:          0.25,
+		Specular:         0.3,
+		Shadows:          true,
+		AmbientOcclusion: true,
+		MaxSteps:         256,
+		Detail:           0.5,
+		Background:       [4]byte{24, 24, 32, 255},
+	}
+}
+
+// Render allocates an image and renders the whole view into it
+func Render(p Params) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, p.Width, p.Height))
+	_ = RenderInto(context.Background(), p, img, 1)
+	return img
+}
+
+// RenderInto ray marches one ray per block of block×block pixels and fills
+// the block with its color, so coarse previews can be drawn quickly. It
+// stops early with the context's error when the context is done.
+func RenderInto(ctx context.Context, p Params, dst *image.RGBA, block int) error {
+	block = max(1, block)
+	rows := make(chan int, runtime.NumCPU())
+	wg := sync.WaitGroup{}
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				for x := 0; x < p.Width; x += block {
+					color := p.trace(float64(x)+float64(block)/2, float64(y)+float64(block)/2, float64(block))
+					for by := y; by < min(y+block, p.Height); by++ {
+						for bx := x; bx < min(x+block, p.Width); bx++ {
+							i := dst.PixOffset(bx, by)
+							copy(dst.Pix[i:i+4], color[:])
+						}
+					}
+				}
+			}
+		}()
+	}
+	var err error
+	for y := 0; y < p.Height; y += block {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		rows <- y
+	}
+	close(rows)
+	wg.Wait()
+	return err
+}
+
+// trace follows the ray through a point of the image and shades what it
+// hits. footprint is the size of the area the ray stands for in pixels.
+func (p *Params) trace(x, y, footprint float64) [4]byte {
+	origin, direction := p.Camera.Ray(x, y, p.Width, p.Height)
+	near, far, ok := intersectSphere(origin, direction, p.Estimator.Radius())
+	if !ok {
+		return p.Background
+	}
+	pixel := p.Camera.PixelAngle(p.Height) * footprint * p.Detail
+
+	t := near
+	for range p.MaxSteps {
+		point := origin.Add(direction.Scale(t))
+		distance, trap := p.Estimator.Estimate(point)
+		epsilon := pixel * t
+		if distance < epsilon {
+			return p.shade(point, direction, epsilon, trap)
+		}
+		t += distance
+		if t > far {
+			break
+		}
+	}
+	return p.Background
+}
+
+// intersectSphere returns where a ray enters and leaves a sphere around the
+// origin, with near clamped to the ray's start
+func intersectSphere(origin, direction Vec3, radius float64) (near, far float64, ok bool) {
+	b := origin.Dot(direction)
+	c := origin.Dot(origin) - radius*radius
+	disc := b*b - c
+	if disc < 0 {
+		return 0, 0, false
+	}
+	root := math.Sqrt(disc)
+	near, far = -b-root, -b+root
+	if far < 0 {
+		return 0, 0, false
+	}
+	return max(0, near), far, true
+}
+
+func (p *Params) shade(point, direction Vec3, epsilon, trap float64) [4]byte {
+	normal := p.normal(point, epsilon)
+	base := p.Palette.ColorAt(trap)
+
+	diffuse := max(0, normal.Dot(p.Light))
+	if diffuse > 0 && p.Shadows {
+		diffuse *= p.shadow(point.Add(normal.Scale(2*epsilon)), epsilon)
+	}
+	occlusion := 1.0
+	if p.AmbientOcclusion {
+		occlusion = p.occlusion(point, normal)
+	}
+	half := p.Light.Sub(direction).Normalize()
+	specular := p.Specular * math.Pow(max(0, normal.Dot(half)), specularShininess) * diffuse
+
+	light := p.Ambient*occlusion + (1-p.Ambient)*diffuse
+	var color [4]byte
+	for i := range 3 {
+		color[i] = byte(min(255, float64(base[i])*light+255*specular))
+	}
+	color[3] = 255
+	return color
+}
+
+// normal is the gradient of the distance estimate by central differences
+func (p *Params) normal(point Vec3, h float64) Vec3 {
+	de := func(q Vec3) float64 {
+		d, _ := p.Estimator.Estimate(q)
+		return d
+	}
+	return Vec3{
+		X: de(point.Add(Vec3{X: h})) - de(point.Sub(Vec3{X: h})),
+		Y: de(point.Add(Vec3{Y: h})) - de(point.Sub(Vec3{Y: h})),
+		Z: de(point.Add(Vec3{Z: h})) - de(point.Sub(Vec3{Z: h})),
+	}.Normalize()
+}
+
+// shadow marches towards the light, darkening by how closely the ray
+// passes the surface on the way
+func (p *Params) shadow(point Vec3, epsilon float64) float64 {
+	_, far, ok := intersectSphere(point, p.Light, p.Estimator.Radius())
+	if !ok {
+		return 1
+	}
+	light := 1.0
+	t := epsilon * 4
+	for range shadowSteps {
+		distance, _ := p.Estimator.Estimate(point.Add(p.Light.Scale(t)))
+		if distance < epsilon/2 {
+			return 0
+		}
+		light = min(light, shadowSharpness*distance/t)
+		t += max(distance, epsilon)
+		if t > far {
+			break
+		}
+	}
+	return light
+}
+
+// occlusion samples the distance estimate along the normal, where nearby
+// surfaces make it come out shorter than the step taken
+func (p *Params) occlusion(point, normal Vec3) float64 {
+	occluded := 0.0
+	weight := 1.0
+	for i := 1; i <= occlusionSamples; i++ {
+		h := occlusionStep * float64(i)
+		distance, _ := p.Estimator.Estimate(point.Add(normal.Scale(h)))
+		occluded += (h - distance) * weight
+		weight *= 0.5
+	}
+	return min(1, max(0, 1-occluded*4))
+}
@@ -4,6 +4,8 @@ package ui
 type Manager interface {
 	Exit()
 	Reset()
+	SaveView()
+	SaveImage()
 	SetExponentReal(exponent float64)
 	SetExponentImag(exponent float64)
 	SetStartingZReal(z float64)
@@ -12,5 +14,69 @@ type Manager interface {
 	SetStartingCImag(z float64)
 	IsJulia() bool
 	SetJulia(julia bool)
+	IsJuliaPreview() bool
+	SetJuliaPreview(preview bool)
+	IsInverseJulia() bool
+	SetInverseJulia(inverse bool)
 	SetMaxIterations(iterations uint64)
+	IsAutoIterations() bool
+	SetAutoIterations(auto bool)
+	Fractals() []string
+	GetFractal() string
+	SetFractal(name string)
+	FractalParameters() []string
+	GetFractalParameter(name string) float64
+	SetFractalParameter(name string, value float64)
+	IsHybrid() bool
+	GetHybrid() string
+	SetHybrid(formulas string)
+	IsLightingEnabled() bool
+	SetLightingEnabled(enabled bool)
+	SetLightAzimuth(degrees float64)
+	SetLightElevation(degrees float64)
+	SetLightHeight(height float64)
+	SetLightAmbient(ambient float64)
+	IsEquipotentials() bool
+	SetEquipotentials(enabled bool)
+	IsFieldLines() bool
+	SetFieldLines(enabled bool)
+	SetEquipotentialDensity(density float64)
+	SetFieldLineDensity(density float64)
+	SetContourColor(hex string)
+	ColoringMethods() []string
+	GetColoringMethod() string
+	SetColoringMethod(method string)
+	SetStripeDensity(density float64)
+	SetColoringSkip(skip int)
+	IsMandelbulb() bool
+	SetMandelbulb(enabled bool)
+	IsQuaternionJulia() bool
+	SetQuaternionJulia(enabled bool)
+	SetMandelbulbPower(power float64)
+	IsShadows() bool
+	SetShadows(shadows bool)
+	IsAmbientOcclusion() bool
+	SetAmbientOcclusion(occlusion bool)
+	SetQuaternionJ(j float64)
+	SetQuaternionK(k float64)
+	SetSliceW(w float64)
+	SetSliceXW(degrees float64)
+	SetSliceYW(degrees float64)
+	SetSliceZW(degrees float64)
+	GetPlaneAngle() float64
+	SetPlaneAngle(degrees float64)
+	RotatePlaneTo(degrees float64)
+	GetPlane() (origin, u, v string)
+	SetPlaneOrigin(origin string)
+	SetPlaneU(u string)
+	SetPlaneV(v string)
+	FindMinibrots(found func(labels []string))
+	ZoomToMinibrot(index int)
+	GetMisiurewiczPreperiod() int
+	SetMisiurewiczPreperiod(preperiod int)
+	GetMisiurewiczPeriod() int
+	SetMisiurewiczPeriod(period int)
+	AddRay(angle string)
+	ClearRays()
+	ZoomToAngle(angle string)
 }
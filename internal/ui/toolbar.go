@@ -4,6 +4,7 @@ import (
 	goimage "image"
 	"image/color"
 	"strconv"
+	"strings"
 
 	"github.com/ebitenui/ebitenui"
 	"github.com/ebitenui/ebitenui/image"
@@ -16,9 +17,16 @@ type Toolbar struct {
 	container    *widget.Container
 	explorerMenu *widget.Button
 	quitButton   *widget.Button
+	checkboxes   []toolbarCheckbox
 }
 
-func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
+// toolbarCheckbox ties a checkbox to the manager state it mirrors
+type toolbarCheckbox struct {
+	checkbox *widget.LabeledCheckbox
+	checked  func() bool
+}
+
+func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) *Toolbar {
 	root := widget.NewContainer(
 		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.Black)),
 
@@ -70,6 +78,11 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 
 	iterations := newToolbarButton(res, "Iterations")
 	var (
+		autoIterations = newToolbarMenuEntryCheckbox(res,
+			"Auto",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetAutoIterations(args.State == widget.WidgetChecked)
+			})
 		iterationsInput = newToolbarNumberEntry(res,
 			"Iters",
 			func(newInputText string) (bool, *string) {
@@ -80,13 +93,18 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 			},
 			func(args *widget.TextInputChangedEventArgs) {
 				if iters, err := strconv.ParseUint(args.TextInput.GetText(), 10, 64); err == nil {
+					// A typed limit overrides auto iterations
+					autoIterations.Checkbox().SetState(widget.WidgetUnchecked)
 					manager.SetMaxIterations(iters)
 				}
 			})
 	)
+	if manager.IsAutoIterations() {
+		autoIterations.Checkbox().SetState(widget.WidgetChecked)
+	}
 	iterations.Configure(
 		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
-			openToolbarMenu(args.Button.GetWidget(), ui, iterationsInput)
+			openToolbarMenu(args.Button.GetWidget(), ui, autoIterations, iterationsInput)
 		}),
 	)
 
@@ -160,6 +178,426 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 		}),
 	)
 
+	fractal := newToolbarButton(res, "Fractal")
+	fractal.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			var entries []widget.PreferredSizeLocateableWidget
+			for _, name := range manager.Fractals() {
+				label := name
+				if name == manager.GetFractal() {
+					label = "> " + name
+				}
+				entry := newToolbarMenuEntry(res, label)
+				entry.Configure(
+					widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+						manager.SetFractal(name)
+					}),
+				)
+				entries = append(entries, entry)
+			}
+			openToolbarMenu(args.Button.GetWidget(), ui, entries...)
+		}),
+	)
+
+	// Parameters differ per fractal, so the menu is built when it opens
+	params := newToolbarButton(res, "Params")
+	params.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			var entries []widget.PreferredSizeLocateableWidget
+			for _, name := range manager.FractalParameters() {
+				entry := newToolbarNumberEntry(res,
+					name,
+					func(newInputText string) (bool, *string) {
+						if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+							return false, nil
+						}
+						return true, &newInputText
+					},
+					func(args *widget.TextInputChangedEventArgs) {
+						if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+							manager.SetFractalParameter(name, f)
+						}
+					})
+				entry.SetText(strconv.FormatFloat(manager.GetFractalParameter(name), 'g', -1, 64))
+				entries = append(entries, entry)
+			}
+			// Hybrids are typed as formulas like mandelbrot,burning-ship^3
+			if manager.IsHybrid() {
+				hybrid := newToolbarNumberEntry(res,
+					"Formulas",
+					func(newInputText string) (bool, *string) {
+						return true, &newInputText
+					},
+					func(args *widget.TextInputChangedEventArgs) {
+						manager.SetHybrid(args.InputText)
+					})
+				hybrid.SetText(manager.GetHybrid())
+				entries = append(entries, hybrid)
+			}
+			openToolbarMenu(args.Button.GetWidget(), ui, entries...)
+		}),
+	)
+
+	lighting := newToolbarButton(res, "Lighting")
+	var (
+		lightingEnabled = newToolbarMenuEntryCheckbox(res,
+			"Enabled",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetLightingEnabled(args.State == widget.WidgetChecked)
+			})
+		lightAzimuth = newToolbarNumberEntry(res,
+			"Azimuth",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetLightAzimuth(f)
+				}
+			})
+		lightElevation = newToolbarNumberEntry(res,
+			"Elevation",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetLightElevation(f)
+				}
+			})
+		lightHeight = newToolbarNumberEntry(res,
+			"Height",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetLightHeight(f)
+				}
+			})
+		lightAmbient = newToolbarNumberEntry(res,
+			"Ambient",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetLightAmbient(f)
+				}
+			})
+	)
+	if manager.IsLightingEnabled() {
+		lightingEnabled.Checkbox().SetState(widget.WidgetChecked)
+	}
+	lighting.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			openToolbarMenu(args.Button.GetWidget(), ui, lightingEnabled, lightAzimuth, lightElevation, lightHeight, lightAmbient)
+		}),
+	)
+
+	coloring := newToolbarButton(res, "Coloring")
+	var (
+		stripeDensity = newToolbarNumberEntry(res,
+			"Stripes",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetStripeDensity(f)
+				}
+			})
+		coloringSkip = newToolbarNumberEntry(res,
+			"Skip",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseUint(newInputText, 10, 31); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if n, err := strconv.ParseUint(args.InputText, 10, 31); err == nil {
+					manager.SetColoringSkip(int(n))
+				}
+			})
+	)
+	coloring.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			var entries []widget.PreferredSizeLocateableWidget
+			for _, method := range manager.ColoringMethods() {
+				label := method
+				if method == manager.GetColoringMethod() {
+					label = "> " + method
+				}
+				entry := newToolbarMenuEntry(res, label)
+				entry.Configure(
+					widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+						manager.SetColoringMethod(method)
+					}),
+				)
+				entries = append(entries, entry)
+			}
+			entries = append(entries, stripeDensity, coloringSkip)
+			openToolbarMenu(args.Button.GetWidget(), ui, entries...)
+		}),
+	)
+
+	contours := newToolbarButton(res, "Contours")
+	var (
+		equipotentials = newToolbarMenuEntryCheckbox(res,
+			"Equipotentials",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetEquipotentials(args.State == widget.WidgetChecked)
+			})
+		fieldLines = newToolbarMenuEntryCheckbox(res,
+			"Field lines",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetFieldLines(args.State == widget.WidgetChecked)
+			})
+		equipotentialDensity = newToolbarNumberEntry(res,
+			"Lines/iter",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetEquipotentialDensity(f)
+				}
+			})
+		fieldLineDensity = newToolbarNumberEntry(res,
+			"Lines/turn",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+					manager.SetFieldLineDensity(f)
+				}
+			})
+		contourColor = newToolbarNumberEntry(res,
+			"#rrggbbaa",
+			func(newInputText string) (bool, *string) {
+				if strings.Trim(strings.TrimPrefix(newInputText, "#"), "0123456789abcdefABCDEF") != "" {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				manager.SetContourColor(args.InputText)
+			})
+	)
+	contours.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			openToolbarMenu(args.Button.GetWidget(), ui, equipotentials, fieldLines, equipotentialDensity, fieldLineDensity, contourColor)
+		}),
+	)
+
+	threeD := newToolbarButton(res, "3D")
+	var (
+		mandelbulb = newToolbarMenuEntryCheckbox(res,
+			"Mandelbulb",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetMandelbulb(args.State == widget.WidgetChecked)
+			})
+		shadows = newToolbarMenuEntryCheckbox(res,
+			"Shadows",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetShadows(args.State == widget.WidgetChecked)
+			})
+		ambientOcclusion = newToolbarMenuEntryCheckbox(res,
+			"Occlusion",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetAmbientOcclusion(args.State == widget.WidgetChecked)
+			})
+		quaternionJulia = newToolbarMenuEntryCheckbox(res,
+			"Quaternion Julia",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetQuaternionJulia(args.State == widget.WidgetChecked)
+			})
+		power       = newToolbarFloatEntry(res, "Power", manager.SetMandelbulbPower)
+		quaternionJ = newToolbarFloatEntry(res, "c j", manager.SetQuaternionJ)
+		quaternionK = newToolbarFloatEntry(res, "c k", manager.SetQuaternionK)
+		sliceW      = newToolbarFloatEntry(res, "Slice w", manager.SetSliceW)
+		sliceXW     = newToolbarFloatEntry(res, "Rotate xw", manager.SetSliceXW)
+		sliceYW     = newToolbarFloatEntry(res, "Rotate yw", manager.SetSliceYW)
+		sliceZW     = newToolbarFloatEntry(res, "Rotate zw", manager.SetSliceZW)
+	)
+	if manager.IsShadows() {
+		shadows.Checkbox().SetState(widget.WidgetChecked)
+	}
+	if manager.IsAmbientOcclusion() {
+		ambientOcclusion.Checkbox().SetState(widget.WidgetChecked)
+	}
+	threeD.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			openToolbarMenu(args.Button.GetWidget(), ui,
+				mandelbulb, quaternionJulia, shadows, ambientOcclusion,
+				power, quaternionJ, quaternionK, sliceW, sliceXW, sliceYW, sliceZW)
+		}),
+	)
+
+	// Planes through starting z and c are at an angle from the Mandelbrot
+	// plane at 0 degrees to the Julia plane at 90, or any plane typed in as
+	// an origin and two directions, each written as z.re,z.im,c.re,c.im
+	plane := newToolbarButton(res, "Plane")
+	var (
+		planeAngle   = newToolbarFloatEntry(res, "Angle", manager.SetPlaneAngle)
+		toJulia      = newToolbarMenuEntry(res, "Turn to Julia")
+		toMandelbrot = newToolbarMenuEntry(res, "Turn to Mandelbrot")
+		planeOrigin  = newToolbarNumberEntry(res, "Origin", validVectorInput, func(args *widget.TextInputChangedEventArgs) {
+			manager.SetPlaneOrigin(args.InputText)
+		})
+		planeU = newToolbarNumberEntry(res, "U", validVectorInput, func(args *widget.TextInputChangedEventArgs) {
+			manager.SetPlaneU(args.InputText)
+		})
+		planeV = newToolbarNumberEntry(res, "V", validVectorInput, func(args *widget.TextInputChangedEventArgs) {
+			manager.SetPlaneV(args.InputText)
+		})
+	)
+	toJulia.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			manager.RotatePlaneTo(90)
+		}),
+	)
+	toMandelbrot.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			manager.RotatePlaneTo(0)
+		}),
+	)
+	plane.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			planeAngle.SetText(strconv.FormatFloat(manager.GetPlaneAngle(), 'g', -1, 64))
+			origin, u, v := manager.GetPlane()
+			planeOrigin.SetText(origin)
+			planeU.SetText(u)
+			planeV.SetText(v)
+			openToolbarMenu(args.Button.GetWidget(), ui, planeAngle, toJulia, toMandelbrot, planeOrigin, planeU, planeV)
+		}),
+	)
+
+	// Minibrots depend on the view, so they are searched for when the menu
+	// opens. The search runs in the background and fills the menu when done.
+	minibrots := newToolbarButton(res, "Minibrots")
+	minibrots.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			opener := args.Button.GetWidget()
+			searching := newToolbarMenuEntry(res, "Searching...")
+			searching.GetWidget().Disabled = true
+			menu := openToolbarMenu(opener, ui, searching)
+			manager.FindMinibrots(func(labels []string) {
+				// Closing the menu gives up on the search
+				if !ui.IsWindowOpen(menu) {
+					return
+				}
+				menu.Close()
+				var entries []widget.PreferredSizeLocateableWidget
+				for i, label := range labels {
+					entry := newToolbarMenuEntry(res, label)
+					entry.Configure(
+						widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+							manager.ZoomToMinibrot(i)
+						}),
+					)
+					entries = append(entries, entry)
+				}
+				if len(entries) == 0 {
+					none := newToolbarMenuEntry(res, "None found")
+					none.GetWidget().Disabled = true
+					entries = append(entries, none)
+				}
+				openToolbarMenu(opener, ui, entries...)
+			})
+		}),
+	)
+
+	// Misiurewicz points are searched for near the cursor with the M key
+	misiurewicz := newToolbarButton(res, "Misiurewicz")
+	var (
+		preperiod = newToolbarNumberEntry(res,
+			"Preperiod",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseUint(newInputText, 10, 31); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if n, err := strconv.ParseUint(args.InputText, 10, 31); err == nil {
+					manager.SetMisiurewiczPreperiod(int(n))
+				}
+			})
+		period = newToolbarNumberEntry(res,
+			"Period",
+			func(newInputText string) (bool, *string) {
+				if _, err := strconv.ParseUint(newInputText, 10, 31); err != nil {
+					return false, nil
+				}
+				return true, &newInputText
+			},
+			func(args *widget.TextInputChangedEventArgs) {
+				if n, err := strconv.ParseUint(args.InputText, 10, 31); err == nil {
+					manager.SetMisiurewiczPeriod(int(n))
+				}
+			})
+	)
+	preperiod.SetText(strconv.Itoa(manager.GetMisiurewiczPreperiod()))
+	period.SetText(strconv.Itoa(manager.GetMisiurewiczPeriod()))
+	misiurewicz.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			openToolbarMenu(args.Button.GetWidget(), ui, preperiod, period)
+		}),
+	)
+
+	// Angles are typed in turns, as fractions like 1/3 or as decimals
+	rays := newToolbarButton(res, "Rays")
+	var (
+		addRay = newToolbarNumberEntry(res,
+			"Draw angle",
+			validAngleInput,
+			func(args *widget.TextInputChangedEventArgs) {
+				manager.AddRay(args.InputText)
+			})
+		zoomToAngle = newToolbarNumberEntry(res,
+			"Go to angle",
+			validAngleInput,
+			func(args *widget.TextInputChangedEventArgs) {
+				manager.ZoomToAngle(args.InputText)
+			})
+		clearRays = newToolbarMenuEntry(res, "Clear")
+	)
+	clearRays.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			manager.ClearRays()
+		}),
+	)
+	rays.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			openToolbarMenu(args.Button.GetWidget(), ui, addRay, zoomToAngle, clearRays)
+		}),
+	)
+
+	var toolbar *Toolbar
 	explorer := newToolbarButton(res, "Explorer")
 	var (
 		julia = newToolbarMenuEntryCheckbox(res,
@@ -175,9 +613,24 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 					c.GetWidget().Disabled = true
 				}
 			})
-		reset = newToolbarMenuEntry(res, "Reset")
-		quit  = newToolbarMenuEntry(res, "Quit")
+		juliaPreview = newToolbarMenuEntryCheckbox(res,
+			"Julia preview",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetJuliaPreview(args.State == widget.WidgetChecked)
+			})
+		inverseJulia = newToolbarMenuEntryCheckbox(res,
+			"Inverse iteration",
+			func(args *widget.CheckboxChangedEventArgs) {
+				manager.SetInverseJulia(args.State == widget.WidgetChecked)
+			})
+		saveView  = newToolbarMenuEntry(res, "Save view")
+		saveImage = newToolbarMenuEntry(res, "Save image")
+		reset     = newToolbarMenuEntry(res, "Reset")
+		quit      = newToolbarMenuEntry(res, "Quit")
 	)
+	if manager.IsInverseJulia() {
+		inverseJulia.Checkbox().SetState(widget.WidgetChecked)
+	}
 	if manager.IsJulia() {
 		z.GetWidget().Disabled = true
 	} else {
@@ -185,7 +638,7 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 	}
 	explorer.Configure(
 		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
-			openToolbarMenu(args.Button.GetWidget(), ui, julia, reset, quit)
+			openToolbarMenu(args.Button.GetWidget(), ui, julia, juliaPreview, inverseJulia, saveView, saveImage, reset, quit)
 		}),
 	)
 	quit.Configure(
@@ -193,24 +646,89 @@ func CreateToolbar(manager Manager, ui *ebitenui.UI, res *resources) {
 			manager.Exit()
 		}),
 	)
+	saveView.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			manager.SaveView()
+		}),
+	)
+	saveImage.Configure(
+		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
+			manager.SaveImage()
+		}),
+	)
 	reset.Configure(
 		widget.ButtonOpts.ClickedHandler(func(args *widget.ButtonClickedEventArgs) {
 			manager.Reset()
+			// Reset can change any of the toggles
+			toolbar.Refresh()
 		}),
 	)
 
 	root.AddChild(explorer)
+	root.AddChild(fractal)
+	root.AddChild(params)
 	root.AddChild(iterations)
 	root.AddChild(exponent)
 	root.AddChild(z)
 	root.AddChild(c)
+	root.AddChild(lighting)
+	root.AddChild(coloring)
+	root.AddChild(contours)
+	root.AddChild(threeD)
+	root.AddChild(plane)
+	root.AddChild(minibrots)
+	root.AddChild(misiurewicz)
+	root.AddChild(rays)
 
-	toolbar := &Toolbar{
+	toolbar = &Toolbar{
 		container:    root,
 		explorerMenu: explorer,
 		quitButton:   quit,
+		checkboxes: []toolbarCheckbox{
+			{checkbox: julia, checked: manager.IsJulia},
+			{checkbox: juliaPreview, checked: manager.IsJuliaPreview},
+			{checkbox: inverseJulia, checked: manager.IsInverseJulia},
+			{checkbox: autoIterations, checked: manager.IsAutoIterations},
+			{checkbox: lightingEnabled, checked: manager.IsLightingEnabled},
+			{checkbox: equipotentials, checked: manager.IsEquipotentials},
+			{checkbox: fieldLines, checked: manager.IsFieldLines},
+			{checkbox: mandelbulb, checked: manager.IsMandelbulb},
+			{checkbox: quaternionJulia, checked: manager.IsQuaternionJulia},
+			{checkbox: shadows, checked: manager.IsShadows},
+			{checkbox: ambientOcclusion, checked: manager.IsAmbientOcclusion},
+		},
 	}
 	ui.Container.AddChild(toolbar.container)
+	return toolbar
+}
+
+// Refresh updates the toolbar to state changed outside of it, such as by
+// keyboard shortcuts
+func (t *Toolbar) Refresh() {
+	for _, c := range t.checkboxes {
+		if c.checked() {
+			c.checkbox.SetState(widget.WidgetChecked)
+		} else {
+			c.checkbox.SetState(widget.WidgetUnchecked)
+		}
+	}
+}
+
+// validAngleInput accepts what can become an angle while it is being typed
+func validAngleInput(newInputText string) (bool, *string) {
+	if strings.Trim(newInputText, "0123456789./") != "" || strings.Count(newInputText, "/") > 1 {
+		return false, nil
+	}
+	return true, &newInputText
+}
+
+// validVectorInput allows what can be typed on the way to four comma
+// separated numbers
+func validVectorInput(newInputText string) (bool, *string) {
+	if strings.Trim(newInputText, "0123456789.,-+eE") != "" || strings.Count(newInputText, ",") > 3 {
+		return false, nil
+	}
+	return true, &newInputText
 }
 
 func newToolbarMenuEntryCheckbox(res *resources, label string, handler widget.CheckboxChangedHandlerFunc) *widget.LabeledCheckbox {
@@ -297,6 +815,23 @@ func newToolbarNumberEntry(res *resources, placeholder string, validator widget.
 	)
 }
 
+// newToolbarFloatEntry is a number entry that passes what is typed to set
+func newToolbarFloatEntry(res *resources, placeholder string, set func(float64)) *widget.TextInput {
+	return newToolbarNumberEntry(res,
+		placeholder,
+		func(newInputText string) (bool, *string) {
+			if _, err := strconv.ParseFloat(newInputText, 64); err != nil {
+				return false, nil
+			}
+			return true, &newInputText
+		},
+		func(args *widget.TextInputChangedEventArgs) {
+			if f, err := strconv.ParseFloat(args.InputText, 64); err == nil {
+				set(f)
+			}
+		})
+}
+
 func newToolbarMenuEntry(res *resources, label string) *widget.Button {
 	return widget.NewButton(
 		widget.ButtonOpts.Image(&widget.ButtonImage{
@@ -320,7 +855,7 @@ func newToolbarMenuEntry(res *resources, label string) *widget.Button {
 	)
 }
 
-func openToolbarMenu(opener *widget.Widget, ui *ebitenui.UI, entries ...widget.PreferredSizeLocateableWidget) {
+func openToolbarMenu(opener *widget.Widget, ui *ebitenui.UI, entries ...widget.PreferredSizeLocateableWidget) *widget.Window {
 	c := widget.NewContainer(
 		widget.ContainerOpts.BackgroundImage(image.NewNineSliceColor(color.RGBA{R: 0, G: 0, B: 0, A: 125})),
 
@@ -358,4 +893,5 @@ func openToolbarMenu(opener *widget.Widget, ui *ebitenui.UI, entries ...widget.P
 	)
 
 	ui.AddWindow(window)
+	return window
 }